@@ -2,15 +2,32 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"regexp"
 
 	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
 )
 
 type GarageClient struct {
-	Client  *garage.APIClient
-	Token   string
-	Scheme  string
-	Host    string
+	Client *garage.APIClient
+	Token  string
+	Scheme string
+	Host   string
+
+	// S3 API settings used for lifecycle policies, objects and computed bucket URLs
+	S3Endpoint        string
+	Region            string
+	S3AddressingStyle string
+	S3RootDomain      string
+	WebRootDomain     string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+
+	// AliasPolicy, when set, must match every global alias of managed buckets
+	AliasPolicy *regexp.Regexp
+
+	// UniqueKeyNames refuses to create or rename a key to a name that is already used
+	UniqueKeyNames bool
 }
 
 func NewGarageClient(scheme, host, token string) (*GarageClient, error) {
@@ -24,6 +41,11 @@ func NewGarageClient(scheme, host, token string) (*GarageClient, error) {
 		Token:  token,
 		Scheme: scheme,
 		Host:   host,
+		// Garage S3 API typically listens on port 3900 next to the admin API on 3903
+		S3Endpoint: fmt.Sprintf("%s://%s", scheme, replacePort(host, 3900)),
+		Region:     "garage",
+		// Buckets are addressed in the URL path unless configured otherwise
+		S3AddressingStyle: s3AddressingPath,
 	}, nil
 }
 
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// bucketFilter holds the filters of the garage_buckets data source.
+type bucketFilter struct {
+	aliasPrefix        string
+	aliasRegex         *regexp.Regexp
+	withoutGlobalAlias bool
+	withoutKeys        bool
+	minSize            int64
+}
+
+// needsDetails reports whether the filter can only be evaluated with GetBucketInfo.
+func (f bucketFilter) needsDetails() bool {
+	return f.withoutKeys || f.minSize > 0
+}
+
+// matchesAliases evaluates the filters that only depend on the ListBuckets output.
+func (f bucketFilter) matchesAliases(globalAliases []string) bool {
+	if f.withoutGlobalAlias {
+		return len(globalAliases) == 0
+	}
+	if f.aliasPrefix == "" && f.aliasRegex == nil {
+		return true
+	}
+	for _, alias := range globalAliases {
+		if f.aliasPrefix != "" && !strings.HasPrefix(alias, f.aliasPrefix) {
+			continue
+		}
+		if f.aliasRegex != nil && !f.aliasRegex.MatchString(alias) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// matchesDetails evaluates the filters that depend on GetBucketInfo.
+func (f bucketFilter) matchesDetails(keyCount int, bytes int64) bool {
+	if f.withoutKeys && keyCount > 0 {
+		return false
+	}
+	if f.minSize > 0 && bytes < f.minSize {
+		return false
+	}
+	return true
+}
+
+func validateRegexp(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value == "" {
+		return
+	}
+	if _, err := regexp.Compile(value); err != nil {
+		errors = append(errors, fmt.Errorf("%q: invalid regular expression: %w", k, err))
+	}
+	return
+}
+
+func dataSourceGarageBuckets() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceGarageBucketsRead,
+		Schema: map[string]*schema.Schema{
+			"alias_prefix": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"without_global_alias"},
+				Description:   "Only return buckets with a global alias starting with this prefix",
+			},
+			"alias_regex": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ValidateFunc:  validateRegexp,
+				ConflictsWith: []string{"without_global_alias"},
+				Description:   "Only return buckets with a global alias matching this regular expression",
+			},
+			"without_global_alias": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Only return buckets that have no global alias",
+			},
+			"without_keys": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Only return buckets that no access key has permissions on",
+			},
+			"min_size": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateCapacity,
+				Description:  "Only return buckets using at least this much storage, with unit suffix (e.g., 500M, 1G)",
+			},
+			"include_details": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Fetch the full bucket information (usage, website, quotas and keys) for every returned bucket",
+			},
+			"concurrency": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      8,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum number of concurrent GetBucketInfo requests",
+			},
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the matching buckets",
+			},
+			"buckets": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Matching buckets",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The bucket ID",
+						},
+						"created": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Creation date",
+						},
+						"global_aliases": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "Global aliases of the bucket",
+						},
+						"local_aliases": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Local aliases of the bucket",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"access_key_id": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "The access key owning the alias",
+									},
+									"alias": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "The local alias",
+									},
+								},
+							},
+						},
+						"bytes": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Total number of bytes used by objects in this bucket (only with details)",
+						},
+						"objects": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of objects in this bucket (only with details)",
+						},
+						"unfinished_uploads": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of unfinished uploads in this bucket (only with details)",
+						},
+						"website_access": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether website access is enabled (only with details)",
+						},
+						"keys": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Access keys with permissions on this bucket (only with details)",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"access_key_id": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "The access key ID",
+									},
+									"name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "The name of the access key",
+									},
+									"read": {
+										Type:        schema.TypeBool,
+										Computed:    true,
+										Description: "Read permission",
+									},
+									"write": {
+										Type:        schema.TypeBool,
+										Computed:    true,
+										Description: "Write permission",
+									},
+									"owner": {
+										Type:        schema.TypeBool,
+										Computed:    true,
+										Description: "Owner permission",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceGarageBucketsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+
+	filter := bucketFilter{
+		aliasPrefix:        d.Get("alias_prefix").(string),
+		withoutGlobalAlias: d.Get("without_global_alias").(bool),
+		withoutKeys:        d.Get("without_keys").(bool),
+	}
+	if v := d.Get("alias_regex").(string); v != "" {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("invalid alias_regex: %w", err))
+		}
+		filter.aliasRegex = re
+	}
+	if v := d.Get("min_size").(string); v != "" {
+		size, err := ParseCapacity(v)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("invalid min_size: %w", err))
+		}
+		filter.minSize = size
+	}
+
+	list, resp, err := client.Client.BucketAPI.ListBuckets(client.WithAuth(ctx)).Execute()
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to list buckets: %w", err))
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	candidates := make([]garage.ListBucketsResponseItem, 0, len(list))
+	for _, item := range list {
+		if filter.matchesAliases(item.GetGlobalAliases()) {
+			candidates = append(candidates, item)
+		}
+	}
+
+	includeDetails := d.Get("include_details").(bool)
+	fetchDetails := includeDetails || filter.needsDetails()
+	details := make([]*garage.GetBucketInfoResponse, len(candidates))
+	if fetchDetails {
+		err := runConcurrently(ctx, len(candidates), d.Get("concurrency").(int), func(ctx context.Context, i int) error {
+			bucket, err := getBucketInfo(ctx, client, candidates[i].GetId())
+			if err != nil {
+				return err
+			}
+			details[i] = bucket
+			return nil
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	ids := make([]string, 0, len(candidates))
+	buckets := make([]map[string]interface{}, 0, len(candidates))
+	for i, item := range candidates {
+		info := details[i]
+		// The bucket was deleted since ListBuckets
+		if fetchDetails && info == nil {
+			continue
+		}
+		if info != nil && !filter.matchesDetails(len(info.GetKeys()), info.GetBytes()) {
+			continue
+		}
+
+		localAliases := make([]map[string]interface{}, 0, len(item.GetLocalAliases()))
+		for _, alias := range item.GetLocalAliases() {
+			localAliases = append(localAliases, map[string]interface{}{
+				"access_key_id": alias.GetAccessKeyId(),
+				"alias":         alias.GetAlias(),
+			})
+		}
+
+		bucket := map[string]interface{}{
+			"id":             item.GetId(),
+			"created":        item.GetCreated().Format(time.RFC3339),
+			"global_aliases": item.GetGlobalAliases(),
+			"local_aliases":  localAliases,
+		}
+		if includeDetails && info != nil {
+			keys := make([]map[string]interface{}, 0, len(info.GetKeys()))
+			for _, key := range info.GetKeys() {
+				perms := key.GetPermissions()
+				keys = append(keys, map[string]interface{}{
+					"access_key_id": key.GetAccessKeyId(),
+					"name":          key.GetName(),
+					"read":          perms.GetRead(),
+					"write":         perms.GetWrite(),
+					"owner":         perms.GetOwner(),
+				})
+			}
+			bucket["bytes"] = int(info.GetBytes())
+			bucket["objects"] = int(info.GetObjects())
+			bucket["unfinished_uploads"] = int(info.GetUnfinishedUploads())
+			bucket["website_access"] = info.GetWebsiteAccess()
+			bucket["keys"] = keys
+		}
+
+		ids = append(ids, item.GetId())
+		buckets = append(buckets, bucket)
+	}
+
+	d.SetId(strconv.Itoa(schema.HashString(strings.Join(ids, ","))))
+	if err := d.Set("ids", ids); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("buckets", buckets); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// getBucketInfo fetches a bucket by ID and closes the response body. It returns
+// nil without error if the bucket does not exist.
+func getBucketInfo(ctx context.Context, client *GarageClient, bucketID string) (*garage.GetBucketInfoResponse, error) {
+	bucket, resp, err := client.Client.BucketAPI.GetBucketInfo(client.WithAuth(ctx)).Id(bucketID).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get bucket info for %s: %w", bucketID, err)
+	}
+	return bucket, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestBucketFilterMatchesAliases(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   bucketFilter
+		aliases  []string
+		expected bool
+	}{
+		{"no filter", bucketFilter{}, []string{"team-a"}, true},
+		{"no filter without alias", bucketFilter{}, nil, true},
+		{"prefix match", bucketFilter{aliasPrefix: "team-"}, []string{"team-a"}, true},
+		{"prefix match on second alias", bucketFilter{aliasPrefix: "team-"}, []string{"legacy", "team-a"}, true},
+		{"prefix mismatch", bucketFilter{aliasPrefix: "team-"}, []string{"other"}, false},
+		{"prefix without alias", bucketFilter{aliasPrefix: "team-"}, nil, false},
+		{"regex match", bucketFilter{aliasRegex: regexp.MustCompile(`-logs$`)}, []string{"app-logs"}, true},
+		{"regex mismatch", bucketFilter{aliasRegex: regexp.MustCompile(`-logs$`)}, []string{"app-data"}, false},
+		{"prefix and regex on same alias", bucketFilter{aliasPrefix: "team-", aliasRegex: regexp.MustCompile(`-logs$`)}, []string{"team-logs"}, true},
+		{"prefix and regex on different aliases", bucketFilter{aliasPrefix: "team-", aliasRegex: regexp.MustCompile(`-logs$`)}, []string{"team-data", "app-logs"}, false},
+		{"without global alias", bucketFilter{withoutGlobalAlias: true}, nil, true},
+		{"without global alias has alias", bucketFilter{withoutGlobalAlias: true}, []string{"team-a"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.filter.matchesAliases(tt.aliases); result != tt.expected {
+				t.Errorf("matchesAliases(%v) = %v, expected %v", tt.aliases, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBucketFilterMatchesDetails(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   bucketFilter
+		keys     int
+		bytes    int64
+		expected bool
+	}{
+		{"no filter", bucketFilter{}, 2, 0, true},
+		{"without keys and no keys", bucketFilter{withoutKeys: true}, 0, 100, true},
+		{"without keys and has keys", bucketFilter{withoutKeys: true}, 1, 100, false},
+		{"min size reached", bucketFilter{minSize: 1024}, 1, 1024, true},
+		{"min size not reached", bucketFilter{minSize: 1024}, 1, 1023, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.filter.matchesDetails(tt.keys, tt.bytes); result != tt.expected {
+				t.Errorf("matchesDetails(%d, %d) = %v, expected %v", tt.keys, tt.bytes, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// clientConfig holds what S3 clients need to reach a Garage cluster with an access key.
+type clientConfig struct {
+	Profile         string
+	AccessKeyID     string
+	SecretAccessKey string
+	Endpoint        string
+	Region          string
+	AddressingStyle string
+	// RootDomain is the provider s3_root_domain, used for virtual-hosted-style buckets
+	RootDomain string
+	Bucket     string
+}
+
+// envVar is an environment variable in the dotenv and Kubernetes Secret renderings.
+type envVar struct {
+	name  string
+	value string
+}
+
+func (c clientConfig) env() []envVar {
+	vars := []envVar{
+		{"AWS_ACCESS_KEY_ID", c.AccessKeyID},
+		{"AWS_SECRET_ACCESS_KEY", c.SecretAccessKey},
+		{"AWS_REGION", c.Region},
+		{"AWS_ENDPOINT_URL_S3", c.Endpoint},
+	}
+	if c.Bucket != "" {
+		vars = append(vars, envVar{"S3_BUCKET", c.Bucket})
+	}
+	return vars
+}
+
+// endpointURL parses the endpoint, which must be an http or https URL.
+func (c clientConfig) endpointURL() (*url.URL, error) {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %q: %w", c.Endpoint, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: expected a URL such as https://s3.example.com", c.Endpoint)
+	}
+	return u, nil
+}
+
+// checkLineValue rejects values that cannot be written on a single line of an INI file.
+func checkLineValue(name, value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("%s cannot contain line breaks", name)
+	}
+	return nil
+}
+
+// awsValue checks a value for the AWS shared files, which have no quoting: the AWS CLI and
+// SDKs strip the whitespace around values.
+func awsValue(name, value string) (string, error) {
+	if err := checkLineValue(name, value); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(value) != value {
+		return "", fmt.Errorf("%s cannot start or end with whitespace in AWS configuration files", name)
+	}
+	return value, nil
+}
+
+// rcloneValue quotes a value for rclone when it would otherwise be altered. rclone takes
+// everything up to the last backtick of a value starting with one.
+func rcloneValue(name, value string) (string, error) {
+	if err := checkLineValue(name, value); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(value) != value || strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "`") {
+		return "`" + value + "`", nil
+	}
+	return value, nil
+}
+
+// s3cmdValue quotes a value for s3cmd when it would otherwise be altered. s3cmd removes a
+// pair of double quotes around a value.
+func s3cmdValue(name, value string) (string, error) {
+	if err := checkLineValue(name, value); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(value) != value || strings.HasPrefix(value, `"`) {
+		return `"` + value + `"`, nil
+	}
+	return value, nil
+}
+
+// dotenvValue quotes a value for dotenv files: in single quotes, which are taken literally,
+// or in double quotes with backslash escapes when the value contains a single quote or a line break.
+func dotenvValue(value string) string {
+	if !strings.ContainsAny(value, "'\r\n") {
+		return "'" + value + "'"
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// iniFile renders INI lines, failing on the first value that cannot be written.
+type iniFile struct {
+	b   strings.Builder
+	err error
+}
+
+func (f *iniFile) section(name string) {
+	if f.b.Len() > 0 {
+		f.b.WriteString("\n")
+	}
+	fmt.Fprintf(&f.b, "[%s]\n", name)
+}
+
+func (f *iniFile) set(indent, key, value string, escape func(name, value string) (string, error)) {
+	if f.err != nil {
+		return
+	}
+	value, f.err = escape(key, value)
+	fmt.Fprintf(&f.b, "%s%s = %s\n", indent, key, value)
+}
+
+func (f *iniFile) render() (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return f.b.String(), nil
+}
+
+// awsCredentials renders the profile of the AWS shared credentials file (~/.aws/credentials).
+func (c clientConfig) awsCredentials() (string, error) {
+	var f iniFile
+	f.section(c.Profile)
+	f.set("", "aws_access_key_id", c.AccessKeyID, awsValue)
+	f.set("", "aws_secret_access_key", c.SecretAccessKey, awsValue)
+	return f.render()
+}
+
+// awsConfig renders the profile of the AWS config file (~/.aws/config).
+func (c clientConfig) awsConfig() (string, error) {
+	if _, err := c.endpointURL(); err != nil {
+		return "", err
+	}
+
+	var f iniFile
+	if c.Profile == "default" {
+		f.section(c.Profile)
+	} else {
+		f.section("profile " + c.Profile)
+	}
+	f.set("", "region", c.Region, awsValue)
+	f.set("", "endpoint_url", c.Endpoint, awsValue)
+	f.b.WriteString("s3 =\n")
+	f.set("  ", "addressing_style", c.AddressingStyle, awsValue)
+	return f.render()
+}
+
+// rcloneConfig renders an S3 remote of rclone.conf.
+func (c clientConfig) rcloneConfig() (string, error) {
+	if _, err := c.endpointURL(); err != nil {
+		return "", err
+	}
+
+	var f iniFile
+	f.section(c.Profile)
+	f.set("", "type", "s3", rcloneValue)
+	f.set("", "provider", "Other", rcloneValue)
+	f.set("", "access_key_id", c.AccessKeyID, rcloneValue)
+	f.set("", "secret_access_key", c.SecretAccessKey, rcloneValue)
+	f.set("", "region", c.Region, rcloneValue)
+	f.set("", "endpoint", c.Endpoint, rcloneValue)
+	f.set("", "force_path_style", strconv.FormatBool(c.AddressingStyle != s3AddressingVirtual), rcloneValue)
+	return f.render()
+}
+
+// s3cmdConfig renders an s3cmd configuration file (~/.s3cfg).
+func (c clientConfig) s3cmdConfig() (string, error) {
+	u, err := c.endpointURL()
+	if err != nil {
+		return "", err
+	}
+	if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" {
+		return "", fmt.Errorf("invalid endpoint %q: s3cmd does not support endpoints with a path", c.Endpoint)
+	}
+
+	hostBucket := u.Host
+	if c.AddressingStyle == s3AddressingVirtual {
+		// Same host as the virtual-hosted URLs of s3ObjectURL
+		hostBucket = "%(bucket)s." + u.Host
+		if domain := strings.Trim(c.RootDomain, "."); domain != "" {
+			hostBucket = "%(bucket)s." + domain
+			if port := u.Port(); port != "" {
+				hostBucket += ":" + port
+			}
+		}
+	}
+	useHTTPS := "False"
+	if u.Scheme == "https" {
+		useHTTPS = "True"
+	}
+
+	var f iniFile
+	f.section("default")
+	f.set("", "access_key", c.AccessKeyID, s3cmdValue)
+	f.set("", "secret_key", c.SecretAccessKey, s3cmdValue)
+	f.set("", "host_base", u.Host, s3cmdValue)
+	f.set("", "host_bucket", hostBucket, s3cmdValue)
+	f.set("", "bucket_location", c.Region, s3cmdValue)
+	f.set("", "use_https", useHTTPS, s3cmdValue)
+	return f.render()
+}
+
+// dotenv renders the environment variables of the configuration as a dotenv file.
+func (c clientConfig) dotenv() string {
+	var b strings.Builder
+	for _, v := range c.env() {
+		fmt.Fprintf(&b, "%s=%s\n", v.name, dotenvValue(v.value))
+	}
+	return b.String()
+}
+
+// kubernetesSecret renders a Kubernetes Secret manifest holding the environment variables of
+// the configuration. Values are base64-encoded in data, so they need no YAML escaping.
+func (c clientConfig) kubernetesSecret(name, namespace string) string {
+	var b strings.Builder
+	b.WriteString("apiVersion: v1\nkind: Secret\nmetadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", name)
+	if namespace != "" {
+		fmt.Fprintf(&b, "  namespace: %s\n", namespace)
+	}
+	b.WriteString("type: Opaque\ndata:\n")
+	for _, v := range c.env() {
+		fmt.Fprintf(&b, "  %s: %s\n", v.name, base64.StdEncoding.EncodeToString([]byte(v.value)))
+	}
+	return b.String()
+}
+
+var (
+	clientProfileRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+	// kubernetesNameRegexp matches DNS subdomain names, used for Secret names and namespaces
+	kubernetesNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+func dataSourceGarageClientConfig() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceGarageClientConfigRead,
+		Schema: map[string]*schema.Schema{
+			"access_key_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The access key ID",
+			},
+			"secret_access_key": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Sensitive:   true,
+				Description: "The secret access key",
+			},
+			"endpoint": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The URL of the S3 API. Defaults to the provider s3_endpoint",
+			},
+			"region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The S3 region. Defaults to the provider s3_region",
+			},
+			"addressing_style": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{s3AddressingPath, s3AddressingVirtual}, false),
+				Description:  "How clients address buckets: path or virtual. Defaults to the provider s3_addressing_style. With virtual, the s3cmd host_bucket uses the provider s3_root_domain when it is set and endpoint is not",
+			},
+			"bucket": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Bucket name exported as S3_BUCKET in dotenv and kubernetes_secret",
+			},
+			"profile": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "garage",
+				ValidateFunc: validation.StringMatch(clientProfileRegexp, "must contain only letters, digits, '_', '.' and '-'"),
+				Description:  "Name of the AWS profile and of the rclone remote",
+			},
+			"kubernetes_secret_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "garage-credentials",
+				ValidateFunc: validation.StringMatch(kubernetesNameRegexp, "must be a lowercase DNS subdomain name"),
+				Description:  "Name of the Kubernetes Secret",
+			},
+			"kubernetes_namespace": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringMatch(kubernetesNameRegexp, "must be a lowercase DNS subdomain name"),
+				Description:  "Namespace of the Kubernetes Secret, omitted if empty",
+			},
+			"aws_credentials": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Profile for the AWS shared credentials file (~/.aws/credentials)",
+			},
+			"aws_config": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Profile for the AWS config file (~/.aws/config) with the region, endpoint and addressing style",
+			},
+			"rclone_config": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "S3 remote for rclone.conf",
+			},
+			"s3cmd_config": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "s3cmd configuration file (~/.s3cfg)",
+			},
+			"dotenv": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Environment variables in dotenv format",
+			},
+			"kubernetes_secret": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Kubernetes Secret manifest (YAML) with the environment variables",
+			},
+		},
+	}
+}
+
+func dataSourceGarageClientConfigRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+
+	cfg := clientConfig{
+		Profile:         d.Get("profile").(string),
+		AccessKeyID:     d.Get("access_key_id").(string),
+		SecretAccessKey: d.Get("secret_access_key").(string),
+		Endpoint:        client.S3Endpoint,
+		Region:          client.Region,
+		AddressingStyle: client.S3AddressingStyle,
+		Bucket:          d.Get("bucket").(string),
+	}
+	// The root domain belongs to the provider endpoint
+	if v := d.Get("endpoint").(string); v != "" {
+		cfg.Endpoint = strings.TrimSuffix(v, "/")
+	} else {
+		cfg.RootDomain = client.S3RootDomain
+	}
+	if v := d.Get("region").(string); v != "" {
+		cfg.Region = v
+	}
+	if v := d.Get("addressing_style").(string); v != "" {
+		cfg.AddressingStyle = v
+	}
+
+	rendered := map[string]string{
+		"dotenv":            cfg.dotenv(),
+		"kubernetes_secret": cfg.kubernetesSecret(d.Get("kubernetes_secret_name").(string), d.Get("kubernetes_namespace").(string)),
+	}
+	renderers := []struct {
+		attribute string
+		render    func() (string, error)
+	}{
+		{"aws_credentials", cfg.awsCredentials},
+		{"aws_config", cfg.awsConfig},
+		{"rclone_config", cfg.rcloneConfig},
+		{"s3cmd_config", cfg.s3cmdConfig},
+	}
+	for _, r := range renderers {
+		value, err := r.render()
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to render %s: %w", r.attribute, err))
+		}
+		rendered[r.attribute] = value
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", cfg.AccessKeyID, cfg.Profile))
+	for attribute, value := range rendered {
+		if err := d.Set(attribute, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func testClientConfig() clientConfig {
+	return clientConfig{
+		Profile:         "garage",
+		AccessKeyID:     "GK31c2f218a2e44f485b94239e",
+		SecretAccessKey: "b892c0665f0ada8a4755dae98baa3b133590e11dae3bcc1f9d769d67f16c3835",
+		Endpoint:        "https://s3.example.com",
+		Region:          "garage",
+		AddressingStyle: s3AddressingPath,
+	}
+}
+
+func TestClientConfigRender(t *testing.T) {
+	cfg := testClientConfig()
+
+	tests := []struct {
+		name     string
+		render   func() (string, error)
+		expected string
+	}{
+		{"aws_credentials", cfg.awsCredentials, `[garage]
+aws_access_key_id = GK31c2f218a2e44f485b94239e
+aws_secret_access_key = b892c0665f0ada8a4755dae98baa3b133590e11dae3bcc1f9d769d67f16c3835
+`},
+		{"aws_config", cfg.awsConfig, `[profile garage]
+region = garage
+endpoint_url = https://s3.example.com
+s3 =
+  addressing_style = path
+`},
+		{"rclone_config", cfg.rcloneConfig, `[garage]
+type = s3
+provider = Other
+access_key_id = GK31c2f218a2e44f485b94239e
+secret_access_key = b892c0665f0ada8a4755dae98baa3b133590e11dae3bcc1f9d769d67f16c3835
+region = garage
+endpoint = https://s3.example.com
+force_path_style = true
+`},
+		{"s3cmd_config", cfg.s3cmdConfig, `[default]
+access_key = GK31c2f218a2e44f485b94239e
+secret_key = b892c0665f0ada8a4755dae98baa3b133590e11dae3bcc1f9d769d67f16c3835
+host_base = s3.example.com
+host_bucket = s3.example.com
+bucket_location = garage
+use_https = True
+`},
+		{"dotenv", func() (string, error) { return cfg.dotenv(), nil }, `AWS_ACCESS_KEY_ID='GK31c2f218a2e44f485b94239e'
+AWS_SECRET_ACCESS_KEY='b892c0665f0ada8a4755dae98baa3b133590e11dae3bcc1f9d769d67f16c3835'
+AWS_REGION='garage'
+AWS_ENDPOINT_URL_S3='https://s3.example.com'
+`},
+		{"kubernetes_secret", func() (string, error) { return cfg.kubernetesSecret("garage-credentials", "apps"), nil }, `apiVersion: v1
+kind: Secret
+metadata:
+  name: garage-credentials
+  namespace: apps
+type: Opaque
+data:
+  AWS_ACCESS_KEY_ID: R0szMWMyZjIxOGEyZTQ0ZjQ4NWI5NDIzOWU=
+  AWS_SECRET_ACCESS_KEY: Yjg5MmMwNjY1ZjBhZGE4YTQ3NTVkYWU5OGJhYTNiMTMzNTkwZTExZGFlM2JjYzFmOWQ3NjlkNjdmMTZjMzgzNQ==
+  AWS_REGION: Z2FyYWdl
+  AWS_ENDPOINT_URL_S3: aHR0cHM6Ly9zMy5leGFtcGxlLmNvbQ==
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.render()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("got:\n%s\nexpected:\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClientConfigVirtualAddressing(t *testing.T) {
+	cfg := testClientConfig()
+	cfg.Profile = "default"
+	cfg.Endpoint = "http://localhost:3900"
+	cfg.AddressingStyle = s3AddressingVirtual
+	cfg.Bucket = "assets"
+
+	tests := []struct {
+		name     string
+		render   func() (string, error)
+		contains []string
+	}{
+		{"aws_config", cfg.awsConfig, []string{"[default]\n", "  addressing_style = virtual\n"}},
+		{"rclone_config", cfg.rcloneConfig, []string{"[default]\n", "force_path_style = false\n"}},
+		{"s3cmd_config", cfg.s3cmdConfig, []string{"host_base = localhost:3900\n", "host_bucket = %(bucket)s.localhost:3900\n", "use_https = False\n"}},
+		{"dotenv", func() (string, error) { return cfg.dotenv(), nil }, []string{"S3_BUCKET='assets'\n"}},
+		{"kubernetes_secret", func() (string, error) { return cfg.kubernetesSecret("creds", ""), nil }, []string{"  S3_BUCKET: YXNzZXRz\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.render()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(result, s) {
+					t.Errorf("expected %q in:\n%s", s, result)
+				}
+			}
+		})
+	}
+}
+
+func TestClientConfigS3cmdRootDomain(t *testing.T) {
+	tests := []struct {
+		name       string
+		endpoint   string
+		style      string
+		rootDomain string
+		expected   string
+	}{
+		{"virtual without root domain", "https://s3.example.com", s3AddressingVirtual, "", "host_bucket = %(bucket)s.s3.example.com\n"},
+		{"virtual with root domain", "https://garage.internal", s3AddressingVirtual, ".s3.example.com", "host_bucket = %(bucket)s.s3.example.com\n"},
+		{"virtual with root domain and port", "http://localhost:3900", s3AddressingVirtual, "s3.garage.localhost", "host_bucket = %(bucket)s.s3.garage.localhost:3900\n"},
+		{"path ignores root domain", "https://garage.internal", s3AddressingPath, ".s3.example.com", "host_bucket = garage.internal\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testClientConfig()
+			cfg.Endpoint = tt.endpoint
+			cfg.AddressingStyle = tt.style
+			cfg.RootDomain = tt.rootDomain
+
+			result, err := cfg.s3cmdConfig()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(result, tt.expected) {
+				t.Errorf("expected %q in:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestClientConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*clientConfig)
+		render func(clientConfig) (string, error)
+	}{
+		{"line break in AWS credentials", func(c *clientConfig) { c.SecretAccessKey = "abc\n[other]" }, clientConfig.awsCredentials},
+		{"whitespace in AWS credentials", func(c *clientConfig) { c.SecretAccessKey = " abc" }, clientConfig.awsCredentials},
+		{"line break in rclone", func(c *clientConfig) { c.Region = "garage\r" }, clientConfig.rcloneConfig},
+		{"line break in s3cmd", func(c *clientConfig) { c.AccessKeyID = "GK\nsecret_key = x" }, clientConfig.s3cmdConfig},
+		{"endpoint without scheme", func(c *clientConfig) { c.Endpoint = "s3.example.com" }, clientConfig.awsConfig},
+		{"endpoint with another scheme", func(c *clientConfig) { c.Endpoint = "ftp://s3.example.com" }, clientConfig.rcloneConfig},
+		{"endpoint with a path for s3cmd", func(c *clientConfig) { c.Endpoint = "https://example.com/s3" }, clientConfig.s3cmdConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testClientConfig()
+			tt.modify(&cfg)
+			if _, err := tt.render(cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestClientConfigValueEscaping(t *testing.T) {
+	tests := []struct {
+		name     string
+		escape   func(string, string) (string, error)
+		value    string
+		expected string
+	}{
+		{"rclone plain", rcloneValue, "a#b;c", "a#b;c"},
+		{"rclone surrounding spaces", rcloneValue, " abc ", "` abc `"},
+		{"rclone leading double quote", rcloneValue, `"abc"`, "`\"abc\"`"},
+		{"rclone leading backtick", rcloneValue, "`a`b", "``a`b`"},
+		{"s3cmd plain", s3cmdValue, "a=b", "a=b"},
+		{"s3cmd surrounding spaces", s3cmdValue, " abc ", `" abc "`},
+		{"s3cmd quoted", s3cmdValue, `"abc"`, `""abc""`},
+		{"aws plain", awsValue, "a = b ; c", "a = b ; c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.escape("value", tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("got %s, expected %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDotenvValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"abc", `'abc'`},
+		{"", `''`},
+		{`a"b$c\d`, `'a"b$c\d'`},
+		{"it's", `"it's"`},
+		{"a'b\"c$d\\e", `"a'b\"c\$d\\e"`},
+		{"line1\nline2", `"line1\nline2"`},
+		{"cr\r", `"cr\r"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if result := dotenvValue(tt.value); result != tt.expected {
+				t.Errorf("dotenvValue(%q) = %s, expected %s", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// keyBucketsSchema describes the buckets an access key has permissions on.
+func keyBucketsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "Buckets the key has permissions on",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"id": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The bucket ID",
+				},
+				"global_aliases": {
+					Type:        schema.TypeList,
+					Computed:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Description: "Global aliases of the bucket",
+				},
+				"local_aliases": {
+					Type:        schema.TypeList,
+					Computed:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Description: "Local aliases of the bucket for this key",
+				},
+				"read": {
+					Type:        schema.TypeBool,
+					Computed:    true,
+					Description: "Read permission",
+				},
+				"write": {
+					Type:        schema.TypeBool,
+					Computed:    true,
+					Description: "Write permission",
+				},
+				"owner": {
+					Type:        schema.TypeBool,
+					Computed:    true,
+					Description: "Owner permission",
+				},
+			},
+		},
+	}
+}
+
+// flattenKeyBuckets converts the bucket list of GetKeyInfo for keyBucketsSchema.
+func flattenKeyBuckets(buckets []garage.KeyInfoBucketResponse) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(buckets))
+	for _, bucket := range buckets {
+		perms := bucket.GetPermissions()
+		result = append(result, map[string]interface{}{
+			"id":             bucket.GetId(),
+			"global_aliases": bucket.GetGlobalAliases(),
+			"local_aliases":  bucket.GetLocalAliases(),
+			"read":           perms.GetRead(),
+			"write":          perms.GetWrite(),
+			"owner":          perms.GetOwner(),
+		})
+	}
+	return result
+}
+
+// uniqueKeyID returns the only ID in ids, the keys matching name.
+func uniqueKeyID(name string, ids []string) (string, error) {
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("no access key named %q", name)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("%d access keys are named %q (%s), use access_key_id instead", len(ids), name, strings.Join(ids, ", "))
+	}
+}
+
+func dataSourceGarageKey() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceGarageKeyRead,
+		Schema: map[string]*schema.Schema{
+			"access_key_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"access_key_id", "name", "search"},
+				Description:  "The access key ID",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The exact name of the access key. Fails if several keys have this name",
+			},
+			"search": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Pattern matched by Garage against the beginning of the key ID or against the key name. Fails if it matches several keys",
+			},
+			"include_secret": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Fetch the secret access key",
+			},
+			"secret_access_key": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The secret access key (only with include_secret)",
+			},
+			"allow_create_bucket": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the key can create buckets through the S3 API",
+			},
+			"created": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Creation date",
+			},
+			"expiration": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Expiration time (RFC3339 format), empty if the key never expires",
+			},
+			"expired": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether this key is expired",
+			},
+			"buckets": keyBucketsSchema(),
+		},
+	}
+}
+
+func dataSourceGarageKeyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+
+	req := client.Client.AccessKeyAPI.GetKeyInfo(client.WithAuth(ctx)).ShowSecretKey(d.Get("include_secret").(bool))
+	lookup := ""
+	switch {
+	case d.Get("search").(string) != "":
+		lookup = d.Get("search").(string)
+		req = req.Search(lookup)
+	case d.Get("access_key_id").(string) != "":
+		lookup = d.Get("access_key_id").(string)
+		req = req.Id(lookup)
+	default:
+		// GetKeyInfo searches names by prefix, match the exact name in ListKeys instead
+		name := d.Get("name").(string)
+		keyID, err := findKeyIDByName(ctx, client, name)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		lookup = name
+		req = req.Id(keyID)
+	}
+
+	key, resp, err := req.Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return diag.FromErr(fmt.Errorf("no access key matches %q", lookup))
+		}
+		// Garage answers 400 when a search matches several keys
+		if resp != nil && resp.StatusCode == http.StatusBadRequest {
+			return diag.FromErr(fmt.Errorf("failed to find a single access key matching %q, use a longer pattern or access_key_id: %w", lookup, err))
+		}
+		return diag.FromErr(fmt.Errorf("failed to read key %q: %w", lookup, err))
+	}
+
+	d.SetId(key.GetAccessKeyId())
+	if err := d.Set("access_key_id", key.GetAccessKeyId()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("name", key.GetName()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("secret_access_key", key.GetSecretAccessKey()); err != nil {
+		return diag.FromErr(err)
+	}
+	permissions := key.GetPermissions()
+	if err := d.Set("allow_create_bucket", permissions.GetCreateBucket()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("created", key.GetCreated().Format(time.RFC3339)); err != nil {
+		return diag.FromErr(err)
+	}
+	expiration := ""
+	if t, ok := key.GetExpirationOk(); ok && t != nil {
+		expiration = t.Format(time.RFC3339)
+	}
+	if err := d.Set("expiration", expiration); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("expired", key.GetExpired()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("buckets", flattenKeyBuckets(key.GetBuckets())); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// findKeyIDByName returns the ID of the only access key with this exact name.
+func findKeyIDByName(ctx context.Context, client *GarageClient, name string) (string, error) {
+	ids, err := keyIDsByName(ctx, client, name)
+	if err != nil {
+		return "", err
+	}
+	return uniqueKeyID(name, ids)
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestUniqueKeyID(t *testing.T) {
+	tests := []struct {
+		name     string
+		ids      []string
+		expected string
+		hasError bool
+	}{
+		{"no match", nil, "", true},
+		{"single match", []string{"GK31c2f218a2e44f485b94239e"}, "GK31c2f218a2e44f485b94239e", false},
+		{"ambiguous", []string{"GK31c2f218a2e44f485b94239e", "GKa8b3e1c0d2f4a6b8c0e2f4a6"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := uniqueKeyID("app", tt.ids)
+			if tt.hasError != (err != nil) {
+				t.Fatalf("uniqueKeyID() error = %v, expected error: %v", err, tt.hasError)
+			}
+			if result != tt.expected {
+				t.Errorf("uniqueKeyID() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+const (
+	keyStatusAll     = "all"
+	keyStatusActive  = "active"
+	keyStatusExpired = "expired"
+)
+
+// keyFilter holds the filters of the garage_keys data source.
+type keyFilter struct {
+	nameRegex *regexp.Regexp
+	status    string
+}
+
+func (f keyFilter) matches(name string, expired bool) bool {
+	if f.nameRegex != nil && !f.nameRegex.MatchString(name) {
+		return false
+	}
+	switch f.status {
+	case keyStatusActive:
+		return !expired
+	case keyStatusExpired:
+		return expired
+	}
+	return true
+}
+
+func dataSourceGarageKeys() *schema.Resource {
+	bucketsSchema := keyBucketsSchema()
+	bucketsSchema.Description = "Buckets the key has permissions on (only with include_buckets)"
+
+	return &schema.Resource{
+		ReadContext: dataSourceGarageKeysRead,
+		Schema: map[string]*schema.Schema{
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRegexp,
+				Description:  "Only return keys with a name matching this regular expression",
+			},
+			"status": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      keyStatusAll,
+				ValidateFunc: validation.StringInSlice([]string{keyStatusAll, keyStatusActive, keyStatusExpired}, false),
+				Description:  "Only return keys with this status: all, active or expired",
+			},
+			"include_buckets": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Fetch the key-level permissions and the bucket permissions of every returned key",
+			},
+			"concurrency": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      8,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum number of concurrent GetKeyInfo requests",
+			},
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the matching keys",
+			},
+			"keys": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Matching keys",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"access_key_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The access key ID",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the access key",
+						},
+						"created": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Creation date",
+						},
+						"expiration": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Expiration time (RFC3339 format), empty if the key never expires",
+						},
+						"expired": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether this key is expired",
+						},
+						"allow_create_bucket": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the key can create buckets through the S3 API (only with include_buckets)",
+						},
+						"buckets": bucketsSchema,
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceGarageKeysRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+
+	filter := keyFilter{status: d.Get("status").(string)}
+	if v := d.Get("name_regex").(string); v != "" {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("invalid name_regex: %w", err))
+		}
+		filter.nameRegex = re
+	}
+
+	list, resp, err := client.Client.AccessKeyAPI.ListKeys(client.WithAuth(ctx)).Execute()
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to list keys: %w", err))
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	matching := make([]garage.ListKeysResponseItem, 0, len(list))
+	for _, item := range list {
+		if filter.matches(item.GetName(), item.GetExpired()) {
+			matching = append(matching, item)
+		}
+	}
+
+	includeBuckets := d.Get("include_buckets").(bool)
+	details := make([]*garage.GetKeyInfoResponse, len(matching))
+	if includeBuckets {
+		err := runConcurrently(ctx, len(matching), d.Get("concurrency").(int), func(ctx context.Context, i int) error {
+			key, resp, err := client.Client.AccessKeyAPI.GetKeyInfo(client.WithAuth(ctx)).Id(matching[i].GetId()).Execute()
+			if resp != nil && resp.Body != nil {
+				_ = resp.Body.Close()
+			}
+			if err != nil {
+				// The key was deleted since ListKeys
+				if resp != nil && resp.StatusCode == http.StatusNotFound {
+					return nil
+				}
+				return fmt.Errorf("failed to read key %s: %w", matching[i].GetId(), err)
+			}
+			details[i] = key
+			return nil
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	ids := make([]string, 0, len(matching))
+	keys := make([]map[string]interface{}, 0, len(matching))
+	for i, item := range matching {
+		if includeBuckets && details[i] == nil {
+			continue
+		}
+
+		expiration := ""
+		if t, ok := item.GetExpirationOk(); ok && t != nil {
+			expiration = t.Format(time.RFC3339)
+		}
+		key := map[string]interface{}{
+			"access_key_id": item.GetId(),
+			"name":          item.GetName(),
+			"created":       item.GetCreated().Format(time.RFC3339),
+			"expiration":    expiration,
+			"expired":       item.GetExpired(),
+		}
+		if info := details[i]; info != nil {
+			permissions := info.GetPermissions()
+			key["allow_create_bucket"] = permissions.GetCreateBucket()
+			key["buckets"] = flattenKeyBuckets(info.GetBuckets())
+		}
+
+		ids = append(ids, item.GetId())
+		keys = append(keys, key)
+	}
+
+	d.SetId(strconv.Itoa(schema.HashString(strings.Join(ids, ","))))
+	if err := d.Set("ids", ids); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("keys", keys); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestKeyFilterMatches(t *testing.T) {
+	naming := regexp.MustCompile(`^(app|ci)-`)
+
+	tests := []struct {
+		name     string
+		filter   keyFilter
+		keyName  string
+		expired  bool
+		expected bool
+	}{
+		{"no filter", keyFilter{status: keyStatusAll}, "anything", false, true},
+		{"no filter expired", keyFilter{status: keyStatusAll}, "anything", true, true},
+		{"regex match", keyFilter{nameRegex: naming}, "app-billing", false, true},
+		{"regex mismatch", keyFilter{nameRegex: naming}, "manual-test", false, false},
+		{"regex on unnamed key", keyFilter{nameRegex: naming}, "", false, false},
+		{"active key", keyFilter{status: keyStatusActive}, "app-billing", false, true},
+		{"active filter on expired key", keyFilter{status: keyStatusActive}, "app-billing", true, false},
+		{"expired key", keyFilter{status: keyStatusExpired}, "app-billing", true, true},
+		{"expired filter on active key", keyFilter{status: keyStatusExpired}, "app-billing", false, false},
+		{"regex and status", keyFilter{nameRegex: naming, status: keyStatusExpired}, "ci-deploy", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.filter.matches(tt.keyName, tt.expired); result != tt.expected {
+				t.Errorf("matches(%q, %v) = %v, expected %v", tt.keyName, tt.expired, result, tt.expected)
+			}
+		})
+	}
+}
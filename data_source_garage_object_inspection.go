@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceGarageObjectInspection() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceGarageObjectInspectionRead,
+		Schema: map[string]*schema.Schema{
+			"bucket_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The bucket ID",
+			},
+			"key": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The object key",
+			},
+			"versions": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Versions of the object stored by Garage, oldest first",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"uuid": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Version UUID",
+						},
+						"timestamp": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Creation date of the version",
+						},
+						"state": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Upload state of the version: complete, uploading, aborted or delete_marker",
+						},
+						"encrypted": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the version is encrypted with SSE-C",
+						},
+						"inline": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the data is stored inline in the object table instead of in data blocks",
+						},
+						"size": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Size of the version in bytes",
+						},
+						"etag": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "ETag of the version",
+						},
+						"blocks": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Data blocks of the version",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"part_number": {
+										Type:        schema.TypeInt,
+										Computed:    true,
+										Description: "Multipart upload part number",
+									},
+									"offset": {
+										Type:        schema.TypeInt,
+										Computed:    true,
+										Description: "Offset of the block in the part",
+									},
+									"hash": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Hash of the block",
+									},
+									"size": {
+										Type:        schema.TypeInt,
+										Computed:    true,
+										Description: "Size of the block in bytes",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// objectVersionState summarizes the upload flags returned by InspectObject.
+func objectVersionState(uploading, aborted, deleteMarker bool) string {
+	switch {
+	case aborted:
+		return "aborted"
+	case uploading:
+		return "uploading"
+	case deleteMarker:
+		return "delete_marker"
+	default:
+		return "complete"
+	}
+}
+
+// flattenObjectBlock maps a data block of an object version to its schema attributes.
+func flattenObjectBlock(partNumber, offset int, hash string, size int) map[string]interface{} {
+	return map[string]interface{}{
+		"part_number": partNumber,
+		"offset":      offset,
+		"hash":        hash,
+		"size":        size,
+	}
+}
+
+func dataSourceGarageObjectInspectionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	bucketID := d.Get("bucket_id").(string)
+	key := d.Get("key").(string)
+
+	object, resp, err := client.Client.BucketAPI.InspectObject(client.WithAuth(ctx)).BucketId(bucketID).Key(key).Execute()
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return diag.FromErr(fmt.Errorf("object %q not found in bucket %s", key, bucketID))
+		}
+		return diag.FromErr(fmt.Errorf("failed to inspect object: %w", err))
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	versions := make([]map[string]interface{}, 0, len(object.GetVersions()))
+	for _, version := range object.GetVersions() {
+		blocks := make([]map[string]interface{}, 0, len(version.GetBlocks()))
+		for _, block := range version.GetBlocks() {
+			blocks = append(blocks, flattenObjectBlock(int(block.GetPartNumber()), int(block.GetOffset()), block.GetHash(), int(block.GetSize())))
+		}
+
+		versions = append(versions, map[string]interface{}{
+			"uuid":      version.GetUuid(),
+			"timestamp": version.GetTimestamp().Format(time.RFC3339),
+			"state":     objectVersionState(version.GetUploading(), version.GetAborted(), version.GetDeleteMarker()),
+			"encrypted": version.GetEncrypted(),
+			"inline":    version.GetInline(),
+			"size":      int(version.GetSize()),
+			"etag":      version.GetEtag(),
+			"blocks":    blocks,
+		})
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", bucketID, key))
+	if err := d.Set("versions", versions); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
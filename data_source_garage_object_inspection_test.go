@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestObjectVersionState(t *testing.T) {
+	tests := []struct {
+		name         string
+		uploading    bool
+		aborted      bool
+		deleteMarker bool
+		expected     string
+	}{
+		{"complete", false, false, false, "complete"},
+		{"uploading", true, false, false, "uploading"},
+		{"aborted", false, true, false, "aborted"},
+		{"aborted while uploading", true, true, false, "aborted"},
+		{"delete marker", false, false, true, "delete_marker"},
+		{"uploading delete marker", true, false, true, "uploading"},
+		{"aborted delete marker", false, true, true, "aborted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := objectVersionState(tt.uploading, tt.aborted, tt.deleteMarker); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFlattenObjectBlock(t *testing.T) {
+	tests := []struct {
+		name       string
+		partNumber int
+		offset     int
+		hash       string
+		size       int
+		expected   map[string]interface{}
+	}{
+		{
+			name:       "first block",
+			partNumber: 1,
+			offset:     0,
+			hash:       "a3f1",
+			size:       1048576,
+			expected:   map[string]interface{}{"part_number": 1, "offset": 0, "hash": "a3f1", "size": 1048576},
+		},
+		{
+			name:       "later part",
+			partNumber: 3,
+			offset:     2097152,
+			hash:       "9bc0",
+			size:       512,
+			expected:   map[string]interface{}{"part_number": 3, "offset": 2097152, "hash": "9bc0", "size": 512},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flattenObjectBlock(tt.partNumber, tt.offset, tt.hash, tt.size)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// websiteDomainBucket returns the name of the bucket Garage serves for a website domain:
+// the part before the S3 or website root domain, or else the whole domain.
+func websiteDomainBucket(domain, s3RootDomain, webRootDomain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	for _, root := range []string{s3RootDomain, webRootDomain} {
+		root = strings.Trim(strings.ToLower(root), ".")
+		if root == "" {
+			continue
+		}
+		if bucket := strings.TrimSuffix(domain, "."+root); bucket != domain && bucket != "" {
+			return bucket
+		}
+	}
+	return domain
+}
+
+// checkWebsiteDomain asks Garage whether it serves domain as a website-enabled bucket.
+func checkWebsiteDomain(ctx context.Context, client *GarageClient, domain string) (bool, error) {
+	resp, err := client.Client.SpecialEndpointsAPI.CheckDomain(client.WithAuth(ctx)).Domain(domain).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	// CheckDomain answers 400 for domains that are not served
+	if resp != nil && resp.StatusCode == http.StatusBadRequest {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check domain %s: %w", domain, err)
+	}
+	return true, nil
+}
+
+func dataSourceGarageWebsiteDomain() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceGarageWebsiteDomainRead,
+		Schema: map[string]*schema.Schema{
+			"domain": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The domain to check (e.g., www.example.com)",
+			},
+			"website_enabled": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether Garage serves the domain from a website-enabled bucket",
+			},
+			"bucket_alias": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Global alias of the bucket Garage looks up for the domain, based on the provider s3_root_domain and web_root_domain",
+			},
+			"bucket_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the bucket with that alias, empty if it does not exist",
+			},
+		},
+	}
+}
+
+func dataSourceGarageWebsiteDomainRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	domain := d.Get("domain").(string)
+
+	enabled, err := checkWebsiteDomain(ctx, client, domain)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	alias := websiteDomainBucket(domain, client.S3RootDomain, client.WebRootDomain)
+	bucketID := ""
+	bucket, resp, err := client.Client.BucketAPI.GetBucketInfo(client.WithAuth(ctx)).GlobalAlias(alias).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return diag.FromErr(fmt.Errorf("failed to get bucket info for %s: %w", alias, err))
+	}
+	if err == nil {
+		bucketID = bucket.GetId()
+	}
+
+	d.SetId(domain)
+	if err := d.Set("website_enabled", enabled); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("bucket_alias", alias); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("bucket_id", bucketID); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
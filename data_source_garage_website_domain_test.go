@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestWebsiteDomainBucket(t *testing.T) {
+	tests := []struct {
+		domain        string
+		s3RootDomain  string
+		webRootDomain string
+		expected      string
+	}{
+		{"www.example.com", "", "", "www.example.com"},
+		{"WWW.Example.com.", "", "", "www.example.com"},
+		{"blog.web.example.com", "", ".web.example.com", "blog"},
+		{"blog.web.example.com", "", "web.example.com", "blog"},
+		{"assets.s3.example.com", ".s3.example.com", ".web.example.com", "assets"},
+		{"www.example.com", ".s3.example.com", ".web.example.com", "www.example.com"},
+		{"web.example.com", "", ".web.example.com", "web.example.com"},
+		{"docs.example.com.web.example.com", "", ".web.example.com", "docs.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			if result := websiteDomainBucket(tt.domain, tt.s3RootDomain, tt.webRootDomain); result != tt.expected {
+				t.Errorf("websiteDomainBucket(%q) = %q, expected %q", tt.domain, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// bucketEndpoints holds the URLs under which a bucket is reachable.
+type bucketEndpoints struct {
+	PathStyle   string
+	VirtualHost string
+	Website     string
+}
+
+// buildBucketEndpoints derives the S3 and website URLs of a bucket from its global alias.
+// Root domains are accepted with or without the leading dot used in Garage's configuration.
+// URLs that cannot be built (no alias or no root domain) are left empty.
+func buildBucketEndpoints(s3Endpoint, s3RootDomain, webRootDomain, alias string) (bucketEndpoints, error) {
+	var endpoints bucketEndpoints
+	if alias == "" {
+		return endpoints, nil
+	}
+
+	u, err := url.Parse(s3Endpoint)
+	if err != nil {
+		return endpoints, fmt.Errorf("invalid S3 endpoint %q: %w", s3Endpoint, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return endpoints, fmt.Errorf("invalid S3 endpoint %q: expected a URL such as https://s3.example.com", s3Endpoint)
+	}
+
+	endpoints.PathStyle = strings.TrimSuffix(s3Endpoint, "/") + "/" + alias
+
+	if domain := strings.Trim(s3RootDomain, "."); domain != "" {
+		host := alias + "." + domain
+		if port := u.Port(); port != "" {
+			host += ":" + port
+		}
+		endpoints.VirtualHost = fmt.Sprintf("%s://%s", u.Scheme, host)
+	}
+
+	if domain := strings.Trim(webRootDomain, "."); domain != "" {
+		endpoints.Website = fmt.Sprintf("%s://%s.%s", u.Scheme, alias, domain)
+	}
+
+	return endpoints, nil
+}
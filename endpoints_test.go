@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestBuildBucketEndpoints(t *testing.T) {
+	tests := []struct {
+		name          string
+		s3Endpoint    string
+		s3RootDomain  string
+		webRootDomain string
+		alias         string
+		expected      bucketEndpoints
+		hasError      bool
+	}{
+		{
+			name:       "path style only",
+			s3Endpoint: "http://127.0.0.1:3900",
+			alias:      "assets",
+			expected:   bucketEndpoints{PathStyle: "http://127.0.0.1:3900/assets"},
+		},
+		{
+			name:          "all urls",
+			s3Endpoint:    "https://s3.example.com/",
+			s3RootDomain:  ".s3.example.com",
+			webRootDomain: ".web.example.com",
+			alias:         "assets",
+			expected: bucketEndpoints{
+				PathStyle:   "https://s3.example.com/assets",
+				VirtualHost: "https://assets.s3.example.com",
+				Website:     "https://assets.web.example.com",
+			},
+		},
+		{
+			name:          "root domains without leading dot and custom port",
+			s3Endpoint:    "http://localhost:3900",
+			s3RootDomain:  "s3.garage.localhost",
+			webRootDomain: "web.garage.localhost",
+			alias:         "site",
+			expected: bucketEndpoints{
+				PathStyle:   "http://localhost:3900/site",
+				VirtualHost: "http://site.s3.garage.localhost:3900",
+				Website:     "http://site.web.garage.localhost",
+			},
+		},
+		{
+			name:         "no alias",
+			s3Endpoint:   "http://localhost:3900",
+			s3RootDomain: ".s3.garage.localhost",
+			expected:     bucketEndpoints{},
+		},
+		{
+			name:       "invalid endpoint",
+			s3Endpoint: "localhost:3900",
+			alias:      "site",
+			hasError:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := buildBucketEndpoints(tt.s3Endpoint, tt.s3RootDomain, tt.webRootDomain, tt.alias)
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("buildBucketEndpoints(%q) expected error, got nil", tt.s3Endpoint)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildBucketEndpoints(%q) unexpected error: %v", tt.s3Endpoint, err)
+			}
+			if result != tt.expected {
+				t.Errorf("buildBucketEndpoints() = %+v, expected %+v", result, tt.expected)
+			}
+		})
+	}
+}
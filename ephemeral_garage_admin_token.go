@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// adminTokenEphemeralResource creates an admin API token when opened and deletes it when closed.
+type adminTokenEphemeralResource struct {
+	client *GarageClient
+}
+
+var (
+	_ ephemeral.EphemeralResourceWithConfigure = &adminTokenEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithClose     = &adminTokenEphemeralResource{}
+)
+
+type adminTokenEphemeralModel struct {
+	Name        types.String `tfsdk:"name"`
+	Scope       []string     `tfsdk:"scope"`
+	ExpiresIn   types.String `tfsdk:"expires_in"`
+	ID          types.String `tfsdk:"id"`
+	SecretToken types.String `tfsdk:"secret_token"`
+	Expiration  types.String `tfsdk:"expiration"`
+}
+
+func NewAdminTokenEphemeralResource() ephemeral.EphemeralResource {
+	return &adminTokenEphemeralResource{}
+}
+
+func (r *adminTokenEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_admin_token"
+}
+
+func (r *adminTokenEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Creates a short-lived admin API token that is deleted when Terraform no longer needs it. The token is never stored in the state.",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				Required:    true,
+				Description: "Name of the admin API token",
+			},
+			"scope": schema.ListAttribute{
+				Required:    true,
+				ElementType: types.StringType,
+				Description: "Scope of the admin API token (list of endpoint names or '*' for all)",
+			},
+			"expires_in": schema.StringAttribute{
+				Optional:    true,
+				Description: "Validity of the token as a duration (e.g., 30m), in case it is not deleted. Defaults to 1h",
+			},
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Identifier of the admin token",
+			},
+			"secret_token": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The secret bearer token",
+			},
+			"expiration": schema.StringAttribute{
+				Computed:    true,
+				Description: "Expiration time (RFC3339 format)",
+			},
+		},
+	}
+}
+
+func (r *adminTokenEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(*GarageClient)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data", fmt.Sprintf("expected *GarageClient, got %T", req.ProviderData))
+		return
+	}
+	r.client = client
+}
+
+func (r *adminTokenEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "The garage provider must be configured before opening ephemeral resources")
+		return
+	}
+
+	var data adminTokenEphemeralModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	expiresIn := ephemeralDefaultExpiresIn
+	if !data.ExpiresIn.IsNull() {
+		expiresIn = data.ExpiresIn.ValueString()
+	}
+	if _, errs := validateDuration(expiresIn, "expires_in"); len(errs) > 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("expires_in"), "Invalid expires_in", errs[0].Error())
+		return
+	}
+	expiration, err := keyExpiration("", expiresIn, time.Now())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("expires_in"), "Invalid expires_in", err.Error())
+		return
+	}
+
+	tokenReq := garage.NewUpdateAdminTokenRequestBody()
+	tokenReq.SetName(data.Name.ValueString())
+	tokenReq.SetScope(data.Scope)
+	tokenReq.SetExpiration(*expiration)
+
+	token, httpResp, err := r.client.Client.AdminAPITokenAPI.CreateAdminToken(r.client.WithAuth(ctx)).UpdateAdminTokenRequestBody(*tokenReq).Execute()
+	if httpResp != nil && httpResp.Body != nil {
+		_ = httpResp.Body.Close()
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create admin token", err.Error())
+		return
+	}
+
+	privateID, err := json.Marshal(token.GetId())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to store admin token ID", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, "id", privateID)...)
+
+	data.ID = types.StringValue(token.GetId())
+	data.SecretToken = types.StringValue(token.GetSecretToken())
+	data.Expiration = types.StringValue(expiration.Format(time.RFC3339))
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
+
+func (r *adminTokenEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	privateID, diags := req.Private.GetKey(ctx, "id")
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || privateID == nil {
+		return
+	}
+
+	var tokenID string
+	if err := json.Unmarshal(privateID, &tokenID); err != nil {
+		resp.Diagnostics.AddError("Failed to read admin token ID", err.Error())
+		return
+	}
+	if err := deleteAdminTokenIfExists(ctx, r.client, tokenID); err != nil {
+		resp.Diagnostics.AddError("Failed to delete admin token", err.Error())
+	}
+}
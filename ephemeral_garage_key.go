@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ephemeralDefaultExpiresIn bounds the lifetime of ephemeral credentials that are never
+// closed, e.g. when Terraform is interrupted.
+const ephemeralDefaultExpiresIn = "1h"
+
+// keyEphemeralResource creates an access key when opened and deletes it when closed.
+type keyEphemeralResource struct {
+	client *GarageClient
+}
+
+var (
+	_ ephemeral.EphemeralResourceWithConfigure = &keyEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithClose     = &keyEphemeralResource{}
+)
+
+type keyEphemeralModel struct {
+	Name              types.String              `tfsdk:"name"`
+	ExpiresIn         types.String              `tfsdk:"expires_in"`
+	AllowCreateBucket types.Bool                `tfsdk:"allow_create_bucket"`
+	BucketPermissions []keyEphemeralBucketModel `tfsdk:"bucket_permissions"`
+	AccessKeyID       types.String              `tfsdk:"access_key_id"`
+	SecretAccessKey   types.String              `tfsdk:"secret_access_key"`
+	Expiration        types.String              `tfsdk:"expiration"`
+}
+
+type keyEphemeralBucketModel struct {
+	BucketID types.String `tfsdk:"bucket_id"`
+	Read     types.Bool   `tfsdk:"read"`
+	Write    types.Bool   `tfsdk:"write"`
+	Owner    types.Bool   `tfsdk:"owner"`
+}
+
+func NewKeyEphemeralResource() ephemeral.EphemeralResource {
+	return &keyEphemeralResource{}
+}
+
+func (r *keyEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_key"
+}
+
+func (r *keyEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Creates a short-lived access key that is deleted when Terraform no longer needs it. The key is never stored in the state.",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				Required:    true,
+				Description: "The name of the access key",
+			},
+			"expires_in": schema.StringAttribute{
+				Optional:    true,
+				Description: "Validity of the key as a duration (e.g., 30m), in case it is not deleted. Defaults to 1h",
+			},
+			"allow_create_bucket": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Allow the key to create buckets through the S3 API",
+			},
+			"access_key_id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The access key ID",
+			},
+			"secret_access_key": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The secret access key",
+			},
+			"expiration": schema.StringAttribute{
+				Computed:    true,
+				Description: "Expiration time (RFC3339 format)",
+			},
+		},
+		// A block, as protocol version 5 does not support nested attributes
+		Blocks: map[string]schema.Block{
+			"bucket_permissions": schema.ListNestedBlock{
+				Description: "Permissions granted to the key on buckets",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"bucket_id": schema.StringAttribute{
+							Required:    true,
+							Description: "The bucket ID",
+						},
+						"read": schema.BoolAttribute{
+							Optional:    true,
+							Description: "Grant read permission",
+						},
+						"write": schema.BoolAttribute{
+							Optional:    true,
+							Description: "Grant write permission",
+						},
+						"owner": schema.BoolAttribute{
+							Optional:    true,
+							Description: "Grant owner permission",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *keyEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(*GarageClient)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data", fmt.Sprintf("expected *GarageClient, got %T", req.ProviderData))
+		return
+	}
+	r.client = client
+}
+
+func (r *keyEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "The garage provider must be configured before opening ephemeral resources")
+		return
+	}
+
+	var data keyEphemeralModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	expiresIn := ephemeralDefaultExpiresIn
+	if !data.ExpiresIn.IsNull() {
+		expiresIn = data.ExpiresIn.ValueString()
+	}
+	if _, errs := validateDuration(expiresIn, "expires_in"); len(errs) > 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("expires_in"), "Invalid expires_in", errs[0].Error())
+		return
+	}
+	expiration, err := keyExpiration("", expiresIn, time.Now())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("expires_in"), "Invalid expires_in", err.Error())
+		return
+	}
+
+	if err := checkKeyNameAvailable(ctx, r.client, data.Name.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), "Key name already used", err.Error())
+		return
+	}
+
+	keyReq := garage.NewUpdateKeyRequestBody()
+	keyReq.SetName(data.Name.ValueString())
+	keyReq.SetExpiration(*expiration)
+	if data.AllowCreateBucket.ValueBool() {
+		perm := garage.NewKeyPerm()
+		perm.SetCreateBucket(true)
+		keyReq.SetAllow(*perm)
+	}
+
+	key, httpResp, err := r.client.Client.AccessKeyAPI.CreateKey(r.client.WithAuth(ctx)).Body(*keyReq).Execute()
+	if httpResp != nil && httpResp.Body != nil {
+		_ = httpResp.Body.Close()
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create key", err.Error())
+		return
+	}
+	keyID := key.GetAccessKeyId()
+
+	for _, bucket := range data.BucketPermissions {
+		err := allowBucketKey(ctx, r.client, keyID, bucket.BucketID.ValueString(), bucket.Read.ValueBool(), bucket.Write.ValueBool(), bucket.Owner.ValueBool())
+		if err != nil {
+			if deleteErr := deleteKeyIfExists(ctx, r.client, keyID); deleteErr != nil {
+				err = fmt.Errorf("%w (deleting the key also failed: %v)", err, deleteErr)
+			}
+			resp.Diagnostics.AddError("Failed to grant bucket permissions", err.Error())
+			return
+		}
+	}
+
+	privateID, err := json.Marshal(keyID)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to store key ID", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, "access_key_id", privateID)...)
+
+	data.AccessKeyID = types.StringValue(keyID)
+	data.SecretAccessKey = types.StringValue(key.GetSecretAccessKey())
+	data.Expiration = types.StringValue(expiration.Format(time.RFC3339))
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
+
+func (r *keyEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	privateID, diags := req.Private.GetKey(ctx, "access_key_id")
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || privateID == nil {
+		return
+	}
+
+	var keyID string
+	if err := json.Unmarshal(privateID, &keyID); err != nil {
+		resp.Diagnostics.AddError("Failed to read key ID", err.Error())
+		return
+	}
+	if err := deleteKeyIfExists(ctx, r.client, keyID); err != nil {
+		resp.Diagnostics.AddError("Failed to delete key", err.Error())
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var bucketURLsAttrTypes = map[string]attr.Type{
+	"s3_path_style_url":   types.StringType,
+	"s3_virtual_host_url": types.StringType,
+	"website_url":         types.StringType,
+}
+
+// bucketURLsFunction builds the same URLs as the computed attributes of garage_bucket.
+type bucketURLsFunction struct{}
+
+var _ function.Function = &bucketURLsFunction{}
+
+func NewBucketURLsFunction() function.Function {
+	return &bucketURLsFunction{}
+}
+
+func (f *bucketURLsFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "bucket_urls"
+}
+
+func (f *bucketURLsFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Build the S3 and website URLs of a bucket",
+		Description: "Returns the path-style S3 URL, the virtual-hosted-style S3 URL and the website URL of a bucket from its global alias. URLs that need an empty root domain are returned as empty strings.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "s3_endpoint",
+				Description: "The URL of the Garage S3 API (e.g., https://s3.example.com)",
+			},
+			function.StringParameter{
+				Name:        "alias",
+				Description: "The global alias of the bucket",
+			},
+			function.StringParameter{
+				Name:        "s3_root_domain",
+				Description: "The root domain for virtual-hosted-style S3 requests (s3_api.root_domain), or an empty string",
+			},
+			function.StringParameter{
+				Name:        "web_root_domain",
+				Description: "The root domain of the Garage website endpoint (s3_web.root_domain), or an empty string",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: bucketURLsAttrTypes,
+		},
+	}
+}
+
+func (f *bucketURLsFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var s3Endpoint, alias, s3RootDomain, webRootDomain string
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &s3Endpoint, &alias, &s3RootDomain, &webRootDomain))
+	if resp.Error != nil {
+		return
+	}
+
+	endpoints, err := buildBucketEndpoints(s3Endpoint, s3RootDomain, webRootDomain, alias)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	result, diags := types.ObjectValue(bucketURLsAttrTypes, map[string]attr.Value{
+		"s3_path_style_url":   types.StringValue(endpoints.PathStyle),
+		"s3_virtual_host_url": types.StringValue(endpoints.VirtualHost),
+		"website_url":         types.StringValue(endpoints.Website),
+	})
+	resp.Error = function.ConcatFuncErrors(resp.Error, function.FuncErrorFromDiags(ctx, diags))
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, result))
+}
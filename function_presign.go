@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// s3PresignMaxExpires is the longest validity SigV4 accepts for presigned URLs.
+const s3PresignMaxExpires = 7 * 24 * time.Hour
+
+// presignFunction computes presigned S3 URLs with the signing code of the provider.
+type presignFunction struct{}
+
+var _ function.Function = &presignFunction{}
+
+func NewPresignFunction() function.Function {
+	return &presignFunction{}
+}
+
+func (f *presignFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "presign"
+}
+
+func (f *presignFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Generate a presigned S3 URL",
+		Description: "Computes a SigV4 presigned URL for an object locally, without any request to Garage. The URL is valid for expires_in seconds after signed_at.",
+		Parameters: []function.Parameter{
+			function.MapParameter{
+				Name:        "s3",
+				ElementType: types.StringType,
+				Description: "S3 settings with the same names as the provider attributes: s3_endpoint (required), s3_region (defaults to garage), s3_addressing_style (path or virtual, defaults to path) and s3_root_domain",
+			},
+			function.StringParameter{
+				Name:        "bucket",
+				Description: "Name (global alias) of the bucket",
+			},
+			function.StringParameter{
+				Name:        "key",
+				Description: "The object key",
+			},
+			function.StringParameter{
+				Name:        "access_key_id",
+				Description: "Access key ID used to sign the URL",
+			},
+			function.StringParameter{
+				Name:        "secret_access_key",
+				Description: "Secret access key used to sign the URL",
+			},
+			function.Int64Parameter{
+				Name:        "expires_in",
+				Description: "Validity of the URL in seconds, at most 604800 (7 days)",
+			},
+			function.StringParameter{
+				Name:        "method",
+				Description: "HTTP method the URL is valid for: GET, HEAD, PUT or DELETE",
+			},
+			function.StringParameter{
+				Name:        "signed_at",
+				Description: "Signing time in RFC 3339 format, such as the result of plantimestamp()",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *presignFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var (
+		settings                                  map[string]string
+		bucket, key, accessKeyID, secretAccessKey string
+		expiresIn                                 int64
+		method, signedAt                          string
+	)
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &settings, &bucket, &key, &accessKeyID, &secretAccessKey, &expiresIn, &method, &signedAt))
+	if resp.Error != nil {
+		return
+	}
+
+	endpoint, region, addressingStyle, rootDomain, err := presignSettings(settings)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	if bucket == "" {
+		resp.Error = function.NewArgumentFuncError(1, "bucket must not be empty")
+		return
+	}
+	if key == "" {
+		resp.Error = function.NewArgumentFuncError(2, "key must not be empty")
+		return
+	}
+	if accessKeyID == "" || secretAccessKey == "" {
+		resp.Error = function.NewArgumentFuncError(3, "access_key_id and secret_access_key must not be empty")
+		return
+	}
+	expires := time.Duration(expiresIn) * time.Second
+	if expires < time.Second || expires > s3PresignMaxExpires {
+		resp.Error = function.NewArgumentFuncError(5, fmt.Sprintf("expires_in must be between 1 and %d seconds", int64(s3PresignMaxExpires/time.Second)))
+		return
+	}
+	method = strings.ToUpper(method)
+	switch method {
+	case "GET", "HEAD", "PUT", "DELETE":
+	default:
+		resp.Error = function.NewArgumentFuncError(6, fmt.Sprintf("unsupported method %q: expected GET, HEAD, PUT or DELETE", method))
+		return
+	}
+	now, err := time.Parse(time.RFC3339, signedAt)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(7, fmt.Sprintf("invalid signed_at: %s", err))
+		return
+	}
+
+	u, err := s3ObjectURL(endpoint, addressingStyle, rootDomain, bucket, key, nil)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	signer := sigV4Signer{accessKeyID: accessKeyID, secretAccessKey: secretAccessKey, region: region}
+	presigned := signer.presignURL(method, u, expires, now)
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, presigned.String()))
+}
+
+// presignSettings reads the S3 settings map of the presign function and applies the
+// same defaults as the provider.
+func presignSettings(settings map[string]string) (endpoint, region, addressingStyle, rootDomain string, err error) {
+	allowed := map[string]bool{"s3_endpoint": true, "s3_region": true, "s3_addressing_style": true, "s3_root_domain": true}
+	var unknown []string
+	for name := range settings {
+		if !allowed[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", "", "", "", fmt.Errorf("unsupported settings %s: expected s3_endpoint, s3_region, s3_addressing_style or s3_root_domain", strings.Join(unknown, ", "))
+	}
+
+	endpoint = strings.TrimSuffix(settings["s3_endpoint"], "/")
+	if endpoint == "" {
+		return "", "", "", "", fmt.Errorf("s3_endpoint is required")
+	}
+	region = settings["s3_region"]
+	if region == "" {
+		region = "garage"
+	}
+	addressingStyle = settings["s3_addressing_style"]
+	if addressingStyle == "" {
+		addressingStyle = s3AddressingPath
+	}
+	return endpoint, region, addressingStyle, settings["s3_root_domain"], nil
+}
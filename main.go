@@ -1,24 +1,53 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"log"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
 )
 
+const providerAddress = "registry.terraform.io/arsolitt/garagehq"
+
 func main() {
 	var debugMode bool
 
 	flag.BoolVar(&debugMode, "debug", false, "set to true to run the provider with support for debuggers like delve")
 	flag.Parse()
 
-	opts := &plugin.ServeOpts{
-		ProviderFunc: func() *schema.Provider {
-			return Provider()
-		},
-		Debug: debugMode,
+	ctx := context.Background()
+
+	muxServer, err := newMuxServer(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var serveOpts []tf5server.ServeOpt
+	if debugMode {
+		serveOpts = append(serveOpts, tf5server.WithManagedDebug())
+	}
+
+	if err := tf5server.Serve(providerAddress, muxServer, serveOpts...); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newMuxServer combines the SDKv2 provider with the framework provider that serves
+// provider-defined functions and ephemeral resources.
+func newMuxServer(ctx context.Context) (func() tfprotov5.ProviderServer, error) {
+	providers := []func() tfprotov5.ProviderServer{
+		Provider().GRPCProvider,
+		providerserver.NewProtocol5(NewFrameworkProvider()),
+	}
+
+	muxServer, err := tf5muxserver.NewMuxServer(ctx, providers...)
+	if err != nil {
+		return nil, err
 	}
 
-	plugin.Serve(opts)
+	return muxServer.ProviderServer, nil
 }
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+)
+
+func TestMuxServerProviderSchema(t *testing.T) {
+	ctx := context.Background()
+
+	muxServer, err := newMuxServer(ctx)
+	if err != nil {
+		t.Fatalf("newMuxServer() unexpected error: %v", err)
+	}
+
+	resp, err := muxServer().GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("GetProviderSchema() unexpected error: %v", err)
+	}
+
+	for _, d := range resp.Diagnostics {
+		if d.Severity == tfprotov5.DiagnosticSeverityError {
+			t.Errorf("GetProviderSchema() error: %s: %s", d.Summary, d.Detail)
+		}
+	}
+}
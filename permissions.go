@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+)
+
+// bucketPerms holds the permission flags of a key on a bucket.
+type bucketPerms struct {
+	Read  bool
+	Write bool
+	Owner bool
+}
+
+func (p bucketPerms) any() bool {
+	return p.Read || p.Write || p.Owner
+}
+
+// bucketPermFlags are the names of the permission flags, as used in schemas.
+var bucketPermFlags = []string{"read", "write", "owner"}
+
+// flags returns the names of the true flags.
+func (p bucketPerms) flags() []string {
+	var flags []string
+	for i, set := range []bool{p.Read, p.Write, p.Owner} {
+		if set {
+			flags = append(flags, bucketPermFlags[i])
+		}
+	}
+	return flags
+}
+
+func (p bucketPerms) String() string {
+	flags := p.flags()
+	if len(flags) == 0 {
+		return "no"
+	}
+	return strings.Join(flags, "+")
+}
+
+// bucketPermsFromFlags is the inverse of flags.
+func bucketPermsFromFlags(flags []interface{}) bucketPerms {
+	var p bucketPerms
+	for _, flag := range flags {
+		switch flag.(string) {
+		case "read":
+			p.Read = true
+		case "write":
+			p.Write = true
+		case "owner":
+			p.Owner = true
+		}
+	}
+	return p
+}
+
+// diffBucketPerms returns the flags to allow and the flags to deny to go from current to desired.
+func diffBucketPerms(current, desired bucketPerms) (allow, deny bucketPerms) {
+	allow = bucketPerms{
+		Read:  desired.Read && !current.Read,
+		Write: desired.Write && !current.Write,
+		Owner: desired.Owner && !current.Owner,
+	}
+	deny = bucketPerms{
+		Read:  current.Read && !desired.Read,
+		Write: current.Write && !desired.Write,
+		Owner: current.Owner && !desired.Owner,
+	}
+	return allow, deny
+}
+
+// keyBucketPerms indexes the bucket list of GetKeyInfo by bucket ID, skipping buckets the key
+// has no permission on.
+func keyBucketPerms(buckets []garage.KeyInfoBucketResponse) map[string]bucketPerms {
+	result := make(map[string]bucketPerms, len(buckets))
+	for _, bucket := range buckets {
+		perms := bucket.GetPermissions()
+		p := bucketPerms{Read: perms.GetRead(), Write: perms.GetWrite(), Owner: perms.GetOwner()}
+		if p.any() {
+			result[bucket.GetId()] = p
+		}
+	}
+	return result
+}
+
+// getKeyBucketPerms returns the permissions of a key on every bucket.
+func getKeyBucketPerms(ctx context.Context, client *GarageClient, keyID string) (map[string]bucketPerms, error) {
+	key, resp, err := client.Client.AccessKeyAPI.GetKeyInfo(client.WithAuth(ctx)).Id(keyID).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", keyID, err)
+	}
+	return keyBucketPerms(key.GetBuckets()), nil
+}
+
+// verifyBucketPerms reads back the permissions of a key on a bucket and fails if they are
+// not the expected ones.
+func verifyBucketPerms(ctx context.Context, client *GarageClient, keyID, bucketID string, expected bucketPerms) error {
+	perms, err := getKeyBucketPerms(ctx, client, keyID)
+	if err != nil {
+		return err
+	}
+	if actual := perms[bucketID]; actual != expected {
+		return fmt.Errorf("key %s has %s permissions on bucket %s, expected %s", keyID, actual, bucketID, expected)
+	}
+	return nil
+}
+
+// setBucketPerms allows and denies the flags needed to go from current to desired.
+func setBucketPerms(ctx context.Context, client *GarageClient, keyID, bucketID string, current, desired bucketPerms) error {
+	allow, deny := diffBucketPerms(current, desired)
+	if allow.any() {
+		if err := allowBucketKey(ctx, client, keyID, bucketID, allow.Read, allow.Write, allow.Owner); err != nil {
+			return err
+		}
+	}
+	if deny.any() {
+		if err := denyBucketKey(ctx, client, keyID, bucketID, deny); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allowBucketKey grants the given permissions of a key on a bucket. False flags are left unchanged.
+func allowBucketKey(ctx context.Context, client *GarageClient, keyID, bucketID string, read, write, owner bool) error {
+	perms := garage.NewApiBucketKeyPerm()
+	perms.SetRead(read)
+	perms.SetWrite(write)
+	perms.SetOwner(owner)
+
+	updateReq := garage.NewBucketKeyPermChangeRequest(keyID, bucketID, *perms)
+	_, resp, err := client.Client.PermissionAPI.AllowBucketKey(client.WithAuth(ctx)).Body(*updateReq).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to allow key %s on bucket %s: %w", keyID, bucketID, err)
+	}
+	return nil
+}
+
+// denyBucketKey revokes the true flags of perms from a key on a bucket. False flags are left unchanged.
+func denyBucketKey(ctx context.Context, client *GarageClient, keyID, bucketID string, perms bucketPerms) error {
+	apiPerms := garage.NewApiBucketKeyPerm()
+	apiPerms.SetRead(perms.Read)
+	apiPerms.SetWrite(perms.Write)
+	apiPerms.SetOwner(perms.Owner)
+
+	updateReq := garage.NewBucketKeyPermChangeRequest(keyID, bucketID, *apiPerms)
+	_, resp, err := client.Client.PermissionAPI.DenyBucketKey(client.WithAuth(ctx)).Body(*updateReq).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to deny key %s on bucket %s: %w", keyID, bucketID, err)
+	}
+	return nil
+}
+
+// bucketPermChange is a change to the permissions of a key on a bucket.
+type bucketPermChange struct {
+	BucketID string
+	Current  bucketPerms
+	Desired  bucketPerms
+}
+
+// planKeyPermissions lists the changes needed for a key to have the desired bucket permissions.
+// Buckets missing from desired lose their permissions when they are managed: every bucket of
+// current in authoritative mode, otherwise only the buckets in previous. Changes are sorted by
+// bucket ID.
+func planKeyPermissions(current, desired map[string]bucketPerms, previous []string, authoritative bool) []bucketPermChange {
+	managed := make(map[string]bool, len(desired))
+	for id := range desired {
+		managed[id] = true
+	}
+	if authoritative {
+		for id := range current {
+			managed[id] = true
+		}
+	} else {
+		for _, id := range previous {
+			managed[id] = true
+		}
+	}
+
+	ids := make([]string, 0, len(managed))
+	for id := range managed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var changes []bucketPermChange
+	for _, id := range ids {
+		if current[id] != desired[id] {
+			changes = append(changes, bucketPermChange{BucketID: id, Current: current[id], Desired: desired[id]})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// allBucketPerms lists the 8 combinations of permission flags.
+func allBucketPerms() []bucketPerms {
+	var result []bucketPerms
+	for i := 0; i < 8; i++ {
+		result = append(result, bucketPerms{Read: i&1 != 0, Write: i&2 != 0, Owner: i&4 != 0})
+	}
+	return result
+}
+
+func TestDiffBucketPerms(t *testing.T) {
+	// allow and deny model AllowBucketKey and DenyBucketKey, which only change true flags
+	allow := func(p, flags bucketPerms) bucketPerms {
+		return bucketPerms{Read: p.Read || flags.Read, Write: p.Write || flags.Write, Owner: p.Owner || flags.Owner}
+	}
+	deny := func(p, flags bucketPerms) bucketPerms {
+		return bucketPerms{Read: p.Read && !flags.Read, Write: p.Write && !flags.Write, Owner: p.Owner && !flags.Owner}
+	}
+
+	for _, current := range allBucketPerms() {
+		for _, desired := range allBucketPerms() {
+			t.Run(fmt.Sprintf("%s to %s", current, desired), func(t *testing.T) {
+				allowed, denied := diffBucketPerms(current, desired)
+				if result := deny(allow(current, allowed), denied); result != desired {
+					t.Errorf("applying allow %s and deny %s to %s gives %s", allowed, denied, current, result)
+				}
+				if (allowed.Read && current.Read) || (allowed.Write && current.Write) || (allowed.Owner && current.Owner) {
+					t.Errorf("allow %s includes flags already set in %s", allowed, current)
+				}
+				if (denied.Read && !current.Read) || (denied.Write && !current.Write) || (denied.Owner && !current.Owner) {
+					t.Errorf("deny %s includes flags not set in %s", denied, current)
+				}
+				if current == desired && (allowed.any() || denied.any()) {
+					t.Errorf("expected no change, got allow %s and deny %s", allowed, denied)
+				}
+			})
+		}
+	}
+}
+
+func TestBucketPermsFlags(t *testing.T) {
+	for _, p := range allBucketPerms() {
+		t.Run(p.String(), func(t *testing.T) {
+			var flags []interface{}
+			for _, flag := range p.flags() {
+				flags = append(flags, flag)
+			}
+			if result := bucketPermsFromFlags(flags); result != p {
+				t.Errorf("bucketPermsFromFlags(%v) = %s", flags, result)
+			}
+		})
+	}
+}
+
+func TestBucketPermsString(t *testing.T) {
+	tests := []struct {
+		perms    bucketPerms
+		expected string
+	}{
+		{bucketPerms{}, "no"},
+		{bucketPerms{Read: true}, "read"},
+		{bucketPerms{Read: true, Write: true, Owner: true}, "read+write+owner"},
+		{bucketPerms{Write: true, Owner: true}, "write+owner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if result := tt.perms.String(); result != tt.expected {
+				t.Errorf("String() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPlanKeyPermissions(t *testing.T) {
+	rw := bucketPerms{Read: true, Write: true}
+	r := bucketPerms{Read: true}
+	all := bucketPerms{Read: true, Write: true, Owner: true}
+
+	tests := []struct {
+		name          string
+		current       map[string]bucketPerms
+		desired       map[string]bucketPerms
+		previous      []string
+		authoritative bool
+		expected      []bucketPermChange
+	}{
+		{
+			name:          "nothing to do",
+			current:       map[string]bucketPerms{"a": rw},
+			desired:       map[string]bucketPerms{"a": rw},
+			authoritative: true,
+		},
+		{
+			name:          "grant new bucket",
+			current:       map[string]bucketPerms{},
+			desired:       map[string]bucketPerms{"a": r},
+			authoritative: true,
+			expected:      []bucketPermChange{{BucketID: "a", Desired: r}},
+		},
+		{
+			name:          "change flags",
+			current:       map[string]bucketPerms{"a": rw},
+			desired:       map[string]bucketPerms{"a": r},
+			authoritative: true,
+			expected:      []bucketPermChange{{BucketID: "a", Current: rw, Desired: r}},
+		},
+		{
+			name:          "authoritative revokes unlisted buckets",
+			current:       map[string]bucketPerms{"a": rw, "b": all, "c": r},
+			desired:       map[string]bucketPerms{"a": rw},
+			authoritative: true,
+			expected:      []bucketPermChange{{BucketID: "b", Current: all}, {BucketID: "c", Current: r}},
+		},
+		{
+			name:     "non-authoritative keeps unlisted buckets",
+			current:  map[string]bucketPerms{"a": rw, "b": all},
+			desired:  map[string]bucketPerms{"a": r},
+			expected: []bucketPermChange{{BucketID: "a", Current: rw, Desired: r}},
+		},
+		{
+			name:     "non-authoritative revokes buckets removed from the configuration",
+			current:  map[string]bucketPerms{"a": rw, "b": all},
+			desired:  map[string]bucketPerms{},
+			previous: []string{"a"},
+			expected: []bucketPermChange{{BucketID: "a", Current: rw}},
+		},
+		{
+			name:     "removed bucket without permissions",
+			current:  map[string]bucketPerms{},
+			desired:  map[string]bucketPerms{},
+			previous: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := planKeyPermissions(tt.current, tt.desired, tt.previous, tt.authoritative)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("planKeyPermissions() = %+v, expected %+v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// readPGPKey parses an ASCII-armored public key that can be used for encryption.
+func readPGPKey(armored string) (*openpgp.Entity, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PGP key: %w", err)
+	}
+	if len(entities) != 1 {
+		return nil, fmt.Errorf("expected exactly one PGP key, got %d", len(entities))
+	}
+	if _, ok := entities[0].EncryptionKey(time.Now()); !ok {
+		return nil, fmt.Errorf("PGP key %X has no valid encryption key", entities[0].PrimaryKey.Fingerprint)
+	}
+	return entities[0], nil
+}
+
+// encryptSecret encrypts secret for an ASCII-armored public key. It returns the base64-encoded
+// binary message and the fingerprint of the key.
+func encryptSecret(armoredKey, secret string) (encrypted, fingerprint string, err error) {
+	entity, err := readPGPKey(armoredKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	w, err := openpgp.Encrypt(&buf, []*openpgp.Entity{entity}, nil, nil, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	if _, err := w.Write([]byte(secret)); err != nil {
+		return "", "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), hex.EncodeToString(entity.PrimaryKey.Fingerprint), nil
+}
+
+// setSecret stores a secret returned by Garage in the secret attribute, or only its
+// encrypted form in encrypted_secret and key_fingerprint when pgp_key is set.
+func setSecret(d *schema.ResourceData, attribute, secret string) error {
+	pgpKey := d.Get("pgp_key").(string)
+	if pgpKey == "" {
+		return d.Set(attribute, secret)
+	}
+
+	encrypted, fingerprint, err := encryptSecret(pgpKey, secret)
+	if err != nil {
+		return err
+	}
+	if err := d.Set(attribute, ""); err != nil {
+		return err
+	}
+	if err := d.Set("encrypted_secret", encrypted); err != nil {
+		return err
+	}
+	return d.Set("key_fingerprint", fingerprint)
+}
+
+func validatePGPKey(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value == "" {
+		return
+	}
+	if _, err := readPGPKey(value); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be an ASCII-armored PGP public key: %w", k, err))
+	}
+	return
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+func armoredPublicKey(t *testing.T, entity *openpgp.Entity) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestEncryptSecret(t *testing.T) {
+	entity, err := openpgp.NewEntity("Garage", "", "ops@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := strings.Repeat("0123456789abcdef", 4)
+
+	encrypted, fingerprint, err := encryptSecret(armoredPublicKey(t, entity), secret)
+	if err != nil {
+		t.Fatalf("encryptSecret() error = %v", err)
+	}
+	if expected := hex.EncodeToString(entity.PrimaryKey.Fingerprint); fingerprint != expected {
+		t.Errorf("fingerprint = %q, expected %q", fingerprint, expected)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		t.Fatalf("encrypted secret is not base64: %v", err)
+	}
+	md, err := openpgp.ReadMessage(bytes.NewReader(data), openpgp.EntityList{entity}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	decrypted, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if string(decrypted) != secret {
+		t.Errorf("decrypted = %q, expected %q", decrypted, secret)
+	}
+}
+
+func TestValidatePGPKey(t *testing.T) {
+	entity, err := openpgp.NewEntity("Garage", "", "ops@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	armored := armoredPublicKey(t, entity)
+
+	tests := []struct {
+		name     string
+		input    string
+		hasError bool
+	}{
+		{"empty", "", false},
+		{"public key", armored, false},
+		{"not armored", "keybase:someone", true},
+		{"truncated", armored[:len(armored)/2], true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := validatePGPKey(tt.input, "pgp_key")
+			if tt.hasError != (len(errs) > 0) {
+				t.Errorf("validatePGPKey() errors = %v, expected error: %v", errs, tt.hasError)
+			}
+		})
+	}
+}
@@ -3,9 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func Provider() *schema.Provider {
@@ -28,26 +31,158 @@ func Provider() *schema.Provider {
 				Sensitive:   true,
 				Description: "The admin token for the Garage admin API",
 			},
+			"s3_endpoint": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The URL of the Garage S3 API (e.g., https://s3.example.com). Defaults to the admin API host on port 3900",
+			},
+			"s3_region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "garage",
+				Description: "The S3 region configured in Garage (s3_api.s3_region)",
+			},
+			"s3_addressing_style": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      s3AddressingPath,
+				ValidateFunc: validation.StringInSlice([]string{s3AddressingPath, s3AddressingVirtual}, false),
+				Description:  "How S3 requests address buckets: path (https://s3.example.com/bucket) or virtual (https://bucket.s3.example.com)",
+			},
+			"s3_root_domain": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The root domain for virtual-hosted-style S3 requests (s3_api.root_domain, e.g., .s3.example.com)",
+			},
+			"web_root_domain": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The root domain of the Garage website endpoint (s3_web.root_domain, e.g., .web.example.com)",
+			},
+			"alias_policy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRegexp,
+				Description:  "A regular expression that every bucket global_alias must match (e.g., ^team-)",
+			},
+			"unique_key_names": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Refuse to create a key, or rename one, when another key already has the same name",
+			},
+			"s3_access_key_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Access key ID used to sign S3 API requests (SigV4). Required by the features that use the S3 API",
+			},
+			"s3_secret_access_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Secret access key used to sign S3 API requests",
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"garage_key":           resourceGarageKey(),
-			"garage_bucket":        resourceGarageBucket(),
-			"garage_bucket_key":    resourceGarageBucketKey(),
-			"garage_admin_token":   resourceGarageAdminToken(),
-			"garage_cluster_layout": resourceGarageClusterLayout(),
+			"garage_key":                          resourceGarageKey(),
+			"garage_key_rotation":                 resourceGarageKeyRotation(),
+			"garage_bucket":                       resourceGarageBucket(),
+			"garage_bucket_key":                   resourceGarageBucketKey(),
+			"garage_key_permissions":              resourceGarageKeyPermissions(),
+			"garage_admin_token":                  resourceGarageAdminToken(),
+			"garage_cluster_layout":               resourceGarageClusterLayout(),
+			"garage_s3_object":                    resourceGarageS3Object(),
+			"garage_bucket_directory_sync":        resourceGarageBucketDirectorySync(),
+			"garage_bucket_website_configuration": resourceGarageBucketWebsiteConfiguration(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"garage_buckets":           dataSourceGarageBuckets(),
+			"garage_object_inspection": dataSourceGarageObjectInspection(),
+			"garage_website_domain":    dataSourceGarageWebsiteDomain(),
+			"garage_key":               dataSourceGarageKey(),
+			"garage_keys":              dataSourceGarageKeys(),
+			"garage_client_config":     dataSourceGarageClientConfig(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
 }
 
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-	scheme := d.Get("scheme").(string)
-	host := d.Get("host").(string)
-	token := d.Get("token").(string)
+	cfg := providerConfig{
+		Scheme:            d.Get("scheme").(string),
+		Host:              d.Get("host").(string),
+		Token:             d.Get("token").(string),
+		S3Endpoint:        d.Get("s3_endpoint").(string),
+		S3Region:          d.Get("s3_region").(string),
+		S3AddressingStyle: d.Get("s3_addressing_style").(string),
+		S3RootDomain:      d.Get("s3_root_domain").(string),
+		WebRootDomain:     d.Get("web_root_domain").(string),
+		AliasPolicy:       d.Get("alias_policy").(string),
+		UniqueKeyNames:    d.Get("unique_key_names").(bool),
+		S3AccessKeyID:     d.Get("s3_access_key_id").(string),
+		S3SecretAccessKey: d.Get("s3_secret_access_key").(string),
+	}
+
+	client, err := cfg.client()
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	return client, nil
+}
+
+// providerConfig holds the provider settings. Both Provider() and the framework provider
+// build their GarageClient from it, so that they behave the same.
+type providerConfig struct {
+	Scheme            string
+	Host              string
+	Token             string
+	S3Endpoint        string
+	S3Region          string
+	S3AddressingStyle string
+	S3RootDomain      string
+	WebRootDomain     string
+	AliasPolicy       string
+	UniqueKeyNames    bool
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+}
+
+// client creates the GarageClient. Empty settings get the defaults of the provider schema.
+func (cfg providerConfig) client() (*GarageClient, error) {
+	scheme := cfg.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
 
-	client, err := NewGarageClient(scheme, host, token)
+	client, err := NewGarageClient(scheme, cfg.Host, cfg.Token)
 	if err != nil {
-		return nil, diag.FromErr(fmt.Errorf("failed to create Garage client: %w", err))
+		return nil, fmt.Errorf("failed to create Garage client: %w", err)
+	}
+
+	if cfg.S3Endpoint != "" {
+		client.S3Endpoint = strings.TrimSuffix(cfg.S3Endpoint, "/")
+	}
+	if cfg.S3Region != "" {
+		client.Region = cfg.S3Region
+	}
+	if cfg.S3AddressingStyle != "" {
+		client.S3AddressingStyle = cfg.S3AddressingStyle
+	}
+	client.S3RootDomain = cfg.S3RootDomain
+	client.WebRootDomain = cfg.WebRootDomain
+	client.UniqueKeyNames = cfg.UniqueKeyNames
+	client.S3AccessKeyID = cfg.S3AccessKeyID
+	client.S3SecretAccessKey = cfg.S3SecretAccessKey
+	if (client.S3AccessKeyID == "") != (client.S3SecretAccessKey == "") {
+		return nil, fmt.Errorf("s3_access_key_id and s3_secret_access_key must be set together")
+	}
+
+	if cfg.AliasPolicy != "" {
+		policy, err := regexp.Compile(cfg.AliasPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alias_policy: %w", err)
+		}
+		client.AliasPolicy = policy
 	}
 
 	return client, nil
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// frameworkProvider serves the features that the SDKv2 provider cannot offer, such as
+// provider-defined functions and ephemeral resources. It is muxed with Provider() in main.
+type frameworkProvider struct{}
+
+var (
+	_ provider.ProviderWithFunctions          = &frameworkProvider{}
+	_ provider.ProviderWithEphemeralResources = &frameworkProvider{}
+)
+
+// frameworkProviderModel is the provider configuration, see Schema.
+type frameworkProviderModel struct {
+	Scheme            types.String `tfsdk:"scheme"`
+	Host              types.String `tfsdk:"host"`
+	Token             types.String `tfsdk:"token"`
+	S3Endpoint        types.String `tfsdk:"s3_endpoint"`
+	S3Region          types.String `tfsdk:"s3_region"`
+	S3AddressingStyle types.String `tfsdk:"s3_addressing_style"`
+	S3RootDomain      types.String `tfsdk:"s3_root_domain"`
+	WebRootDomain     types.String `tfsdk:"web_root_domain"`
+	AliasPolicy       types.String `tfsdk:"alias_policy"`
+	UniqueKeyNames    types.Bool   `tfsdk:"unique_key_names"`
+	S3AccessKeyID     types.String `tfsdk:"s3_access_key_id"`
+	S3SecretAccessKey types.String `tfsdk:"s3_secret_access_key"`
+}
+
+func NewFrameworkProvider() provider.Provider {
+	return &frameworkProvider{}
+}
+
+func (p *frameworkProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "garage"
+}
+
+// Schema mirrors the schema of Provider(). The mux server requires every underlying
+// provider to return the same provider schema, so both must be changed together.
+func (p *frameworkProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"scheme": schema.StringAttribute{
+				Optional:    true,
+				Description: "The scheme to use for the Garage admin API",
+			},
+			"host": schema.StringAttribute{
+				Required:    true,
+				Description: "The host and port for the Garage admin API (e.g., 127.0.0.1:3903)",
+			},
+			"token": schema.StringAttribute{
+				Required:    true,
+				Sensitive:   true,
+				Description: "The admin token for the Garage admin API",
+			},
+			"s3_endpoint": schema.StringAttribute{
+				Optional:    true,
+				Description: "The URL of the Garage S3 API (e.g., https://s3.example.com). Defaults to the admin API host on port 3900",
+			},
+			"s3_region": schema.StringAttribute{
+				Optional:    true,
+				Description: "The S3 region configured in Garage (s3_api.s3_region)",
+			},
+			"s3_addressing_style": schema.StringAttribute{
+				Optional:    true,
+				Description: "How S3 requests address buckets: path (https://s3.example.com/bucket) or virtual (https://bucket.s3.example.com)",
+			},
+			"s3_root_domain": schema.StringAttribute{
+				Optional:    true,
+				Description: "The root domain for virtual-hosted-style S3 requests (s3_api.root_domain, e.g., .s3.example.com)",
+			},
+			"web_root_domain": schema.StringAttribute{
+				Optional:    true,
+				Description: "The root domain of the Garage website endpoint (s3_web.root_domain, e.g., .web.example.com)",
+			},
+			"alias_policy": schema.StringAttribute{
+				Optional:    true,
+				Description: "A regular expression that every bucket global_alias must match (e.g., ^team-)",
+			},
+			"unique_key_names": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Refuse to create a key, or rename one, when another key already has the same name",
+			},
+			"s3_access_key_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "Access key ID used to sign S3 API requests (SigV4). Required by the features that use the S3 API",
+			},
+			"s3_secret_access_key": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Secret access key used to sign S3 API requests",
+			},
+		},
+	}
+}
+
+// Configure creates the same GarageClient as providerConfigure. Values are validated by
+// Provider(), which the mux server also asks to validate the configuration.
+func (p *frameworkProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var data frameworkProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Unknown values are only known during apply, when Configure is called again
+	if data.Host.IsUnknown() || data.Token.IsUnknown() {
+		return
+	}
+
+	cfg := providerConfig{
+		Scheme:            data.Scheme.ValueString(),
+		Host:              data.Host.ValueString(),
+		Token:             data.Token.ValueString(),
+		S3Endpoint:        data.S3Endpoint.ValueString(),
+		S3Region:          data.S3Region.ValueString(),
+		S3AddressingStyle: data.S3AddressingStyle.ValueString(),
+		S3RootDomain:      data.S3RootDomain.ValueString(),
+		WebRootDomain:     data.WebRootDomain.ValueString(),
+		AliasPolicy:       data.AliasPolicy.ValueString(),
+		UniqueKeyNames:    data.UniqueKeyNames.ValueBool(),
+		S3AccessKeyID:     data.S3AccessKeyID.ValueString(),
+		S3SecretAccessKey: data.S3SecretAccessKey.ValueString(),
+	}
+
+	client, err := cfg.client()
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to configure the Garage provider", err.Error())
+		return
+	}
+
+	resp.EphemeralResourceData = client
+}
+
+func (p *frameworkProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return nil
+}
+
+func (p *frameworkProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return nil
+}
+
+func (p *frameworkProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewKeyEphemeralResource,
+		NewAdminTokenEphemeralResource,
+	}
+}
+
+func (p *frameworkProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewBucketURLsFunction,
+		NewPresignFunction,
+	}
+}
@@ -19,9 +19,18 @@ func resourceGarageAdminToken() *schema.Resource {
 		DeleteContext: resourceGarageAdminTokenDelete,
 		Schema: map[string]*schema.Schema{
 			"name": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Name of the admin API token",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"name_prefix"},
+				Description:   "Name of the admin API token. If omitted, a unique name is generated, beginning with name_prefix if set",
+			},
+			"name_prefix": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"name"},
+				Description:   "Creates a unique name beginning with this prefix",
 			},
 			"scope": {
 				Type:        schema.TypeList,
@@ -40,6 +49,23 @@ func resourceGarageAdminToken() *schema.Resource {
 				Sensitive:   true,
 				Description: "The secret bearer token (only available on create)",
 			},
+			"pgp_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validatePGPKey,
+				Description:  "ASCII-armored PGP public key. When set, only the encrypted secret token is stored in the state",
+			},
+			"encrypted_secret": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The secret token encrypted with pgp_key, base64-encoded",
+			},
+			"key_fingerprint": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Fingerprint of the PGP key used to encrypt the secret token",
+			},
 			"expiration": {
 				Type:         schema.TypeString,
 				Optional:     true,
@@ -81,7 +107,7 @@ func resourceGarageAdminTokenCreate(ctx context.Context, d *schema.ResourceData,
 	client := m.(*GarageClient)
 
 	req := garage.NewUpdateAdminTokenRequestBody()
-	req.SetName(d.Get("name").(string))
+	req.SetName(resourceName(d))
 
 	scope := expandStringList(d.Get("scope").([]interface{}))
 	req.SetScope(scope)
@@ -123,7 +149,7 @@ func resourceGarageAdminTokenCreate(ctx context.Context, d *schema.ResourceData,
 	if err := d.Set("scope", token.GetScope()); err != nil {
 		return diag.FromErr(err)
 	}
-	if err := d.Set("secret_token", token.GetSecretToken()); err != nil {
+	if err := setSecret(d, "secret_token", token.GetSecretToken()); err != nil {
 		return diag.FromErr(err)
 	}
 	if err := d.Set("expired", token.GetExpired()); err != nil {
@@ -251,6 +277,21 @@ func resourceGarageAdminTokenDelete(ctx context.Context, d *schema.ResourceData,
 	return nil
 }
 
+// deleteAdminTokenIfExists deletes an admin token. Deleting a missing token is not an error.
+func deleteAdminTokenIfExists(ctx context.Context, client *GarageClient, tokenID string) error {
+	resp, err := client.Client.AdminAPITokenAPI.DeleteAdminToken(client.WithAuth(ctx)).Id(tokenID).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete admin token %s: %w", tokenID, err)
+	}
+	return nil
+}
+
 func expandStringList(l []interface{}) []string {
 	result := make([]string, len(l))
 	for i, v := range l {
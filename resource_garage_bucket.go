@@ -1,11 +1,12 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
@@ -19,6 +20,7 @@ func resourceGarageBucket() *schema.Resource {
 		ReadContext:   resourceGarageBucketRead,
 		UpdateContext: resourceGarageBucketUpdate,
 		DeleteContext: resourceGarageBucketDelete,
+		CustomizeDiff: resourceGarageBucketCustomizeDiff,
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:        schema.TypeString,
@@ -27,9 +29,55 @@ func resourceGarageBucket() *schema.Resource {
 				Description: "The bucket ID (computed if not provided)",
 			},
 			"global_alias": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "Global alias for the bucket (this appears as the name in garage bucket list)",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateBucketName,
+				Description:  "Global alias for the bucket (this appears as the name in garage bucket list)",
+			},
+			"local_alias": {
+				Type:             schema.TypeList,
+				Optional:         true,
+				DiffSuppressFunc: suppressAfterCreate,
+				MaxItems:         1,
+				Description:      "Local alias and permissions of an access key, set when the bucket is created and ignored afterwards",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"access_key_id": {
+							Type:             schema.TypeString,
+							Required:         true,
+							DiffSuppressFunc: suppressAfterCreate,
+							Description:      "The access key that owns the local alias",
+						},
+						"alias": {
+							Type:             schema.TypeString,
+							Required:         true,
+							DiffSuppressFunc: suppressAfterCreate,
+							ValidateFunc:     validateBucketName,
+							Description:      "The local alias of the bucket in the namespace of the access key",
+						},
+						"read": {
+							Type:             schema.TypeBool,
+							Optional:         true,
+							DiffSuppressFunc: suppressAfterCreate,
+							Default:          false,
+							Description:      "Grant read permission",
+						},
+						"write": {
+							Type:             schema.TypeBool,
+							Optional:         true,
+							DiffSuppressFunc: suppressAfterCreate,
+							Default:          false,
+							Description:      "Grant write permission",
+						},
+						"owner": {
+							Type:             schema.TypeBool,
+							Optional:         true,
+							DiffSuppressFunc: suppressAfterCreate,
+							Default:          false,
+							Description:      "Grant owner permission",
+						},
+					},
+				},
 			},
 			"bytes": {
 				Type:        schema.TypeInt,
@@ -46,10 +94,54 @@ func resourceGarageBucket() *schema.Resource {
 				Optional:    true,
 				Description: "Number of days after which objects in this bucket will be automatically deleted. Set to 0 to disable expiration.",
 			},
+			"region": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The S3 region of the bucket",
+			},
+			"s3_path_style_url": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Path-style S3 URL of the bucket (requires global_alias)",
+			},
+			"s3_virtual_host_url": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Virtual-hosted-style S3 URL of the bucket (requires global_alias and the provider s3_root_domain)",
+			},
+			"website_url": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Website URL of the bucket (requires global_alias and the provider web_root_domain)",
+			},
 		},
 	}
 }
 
+// resourceGarageBucketCustomizeDiff checks the global alias against the provider alias_policy,
+// and that the provider can sign the S3 requests setting expiration_days
+func resourceGarageBucketCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	client, ok := m.(*GarageClient)
+	if !ok {
+		return nil
+	}
+
+	if d.Get("expiration_days").(int) > 0 && !client.hasS3Credentials() {
+		return fmt.Errorf("expiration_days is set through the S3 API: %w", errMissingS3Credentials)
+	}
+
+	if client.AliasPolicy == nil || !d.NewValueKnown("global_alias") {
+		return nil
+	}
+
+	alias := d.Get("global_alias").(string)
+	if alias != "" && !client.AliasPolicy.MatchString(alias) {
+		return fmt.Errorf("global_alias %q does not match the provider alias_policy %q", alias, client.AliasPolicy.String())
+	}
+
+	return nil
+}
+
 func resourceGarageBucketCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*GarageClient)
 	globalAlias := d.Get("global_alias").(string)
@@ -58,6 +150,9 @@ func resourceGarageBucketCreate(ctx context.Context, d *schema.ResourceData, m i
 	if globalAlias != "" {
 		bucketReq.SetGlobalAlias(globalAlias)
 	}
+	if v, ok := d.GetOk("local_alias"); ok {
+		bucketReq.SetLocalAlias(expandBucketLocalAlias(v.([]interface{})[0].(map[string]interface{})))
+	}
 
 	bucket, resp, err := client.Client.BucketAPI.CreateBucket(client.WithAuth(ctx)).CreateBucketRequest(*bucketReq).Execute()
 	if err != nil {
@@ -84,6 +179,9 @@ func resourceGarageBucketCreate(ctx context.Context, d *schema.ResourceData, m i
 			return diag.FromErr(err)
 		}
 	}
+	if err := setBucketEndpoints(d, client, d.Get("global_alias").(string)); err != nil {
+		return diag.FromErr(err)
+	}
 
 	// Set expiration policy if specified
 	if expirationDays, ok := d.GetOk("expiration_days"); ok && expirationDays.(int) > 0 {
@@ -130,9 +228,15 @@ func resourceGarageBucketRead(ctx context.Context, d *schema.ResourceData, m int
 			return diag.FromErr(err)
 		}
 	}
+	if err := setBucketEndpoints(d, client, d.Get("global_alias").(string)); err != nil {
+		return diag.FromErr(err)
+	}
 
 	// Read expiration policy if it exists
 	expirationDays, err := getBucketLifecyclePolicy(ctx, client, bucket.GetId())
+	if errors.Is(err, errMissingS3Credentials) && d.Get("expiration_days").(int) > 0 {
+		return diag.FromErr(fmt.Errorf("failed to read expiration policy: %w", err))
+	}
 	if err == nil && expirationDays > 0 {
 		if err := d.Set("expiration_days", expirationDays); err != nil {
 			return diag.FromErr(err)
@@ -187,6 +291,43 @@ func resourceGarageBucketDelete(ctx context.Context, d *schema.ResourceData, m i
 	return nil
 }
 
+// suppressAfterCreate ignores changes to attributes that are only used when the resource is
+// created, so that changing them or importing the resource does not force a new one.
+func suppressAfterCreate(k, old, new string, d *schema.ResourceData) bool {
+	return d.Id() != ""
+}
+
+// expandBucketLocalAlias converts a local_alias block to the CreateBucket local alias.
+func expandBucketLocalAlias(l map[string]interface{}) garage.CreateBucketLocalAlias {
+	perms := garage.NewApiBucketKeyPerm()
+	perms.SetRead(l["read"].(bool))
+	perms.SetWrite(l["write"].(bool))
+	perms.SetOwner(l["owner"].(bool))
+
+	localAlias := garage.NewCreateBucketLocalAlias(l["access_key_id"].(string), l["alias"].(string))
+	localAlias.SetAllow(*perms)
+	return *localAlias
+}
+
+// setBucketEndpoints sets the region and the computed URLs of a bucket from its global alias
+func setBucketEndpoints(d *schema.ResourceData, client *GarageClient, alias string) error {
+	endpoints, err := buildBucketEndpoints(client.S3Endpoint, client.S3RootDomain, client.WebRootDomain, alias)
+	if err != nil {
+		return err
+	}
+
+	if err := d.Set("region", client.Region); err != nil {
+		return err
+	}
+	if err := d.Set("s3_path_style_url", endpoints.PathStyle); err != nil {
+		return err
+	}
+	if err := d.Set("s3_virtual_host_url", endpoints.VirtualHost); err != nil {
+		return err
+	}
+	return d.Set("website_url", endpoints.Website)
+}
+
 // S3 Lifecycle Configuration structures
 type LifecycleConfiguration struct {
 	XMLName xml.Name `xml:"LifecycleConfiguration"`
@@ -208,6 +349,9 @@ type Expiration struct {
 	Days int `xml:"Days"`
 }
 
+// lifecycleQuery selects the lifecycle subresource of a bucket
+var lifecycleQuery = url.Values{"lifecycle": {""}}
+
 // setBucketLifecyclePolicy sets the lifecycle expiration policy for a bucket using S3-compatible API
 func setBucketLifecyclePolicy(ctx context.Context, client *GarageClient, bucketID string, expirationDays int) error {
 	// Get bucket info to find the bucket alias/name
@@ -227,13 +371,6 @@ func setBucketLifecyclePolicy(ctx context.Context, client *GarageClient, bucketI
 		bucketName = aliases[0]
 	}
 
-	// Construct S3 endpoint URL (Garage S3 API typically uses port 3900)
-	// Replace admin port (3903) with S3 port (3900)
-	s3URL := fmt.Sprintf("%s://%s/%s?lifecycle",
-		client.Scheme,
-		replacePort(client.Host, 3900),
-		bucketName)
-
 	// Create lifecycle configuration XML
 	lifecycleConfig := LifecycleConfiguration{
 		Rules: []Rule{
@@ -252,20 +389,10 @@ func setBucketLifecyclePolicy(ctx context.Context, client *GarageClient, bucketI
 		return fmt.Errorf("failed to marshal lifecycle config: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "PUT", s3URL, bytes.NewReader(xmlData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/xml")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.Token))
-
-	// Execute request
-	httpClient := &http.Client{}
-	resp, err = httpClient.Do(req)
+	resp, err = client.s3Request(ctx, http.MethodPut, bucketName, "", lifecycleQuery, xmlData,
+		http.Header{"Content-Type": {"application/xml"}})
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return err
 	}
 	defer func() {
 		if resp.Body != nil {
@@ -299,26 +426,9 @@ func getBucketLifecyclePolicy(ctx context.Context, client *GarageClient, bucketI
 		bucketName = aliases[0]
 	}
 
-	// Construct S3 endpoint URL
-	s3URL := fmt.Sprintf("%s://%s/%s?lifecycle",
-		client.Scheme,
-		replacePort(client.Host, 3900),
-		bucketName)
-
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "GET", s3URL, nil)
+	resp, err = client.s3Request(ctx, http.MethodGet, bucketName, "", lifecycleQuery, nil, nil)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Use admin token for authorization
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.Token))
-
-	// Execute request
-	httpClient := &http.Client{}
-	resp, err = httpClient.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("failed to execute request: %w", err)
+		return 0, err
 	}
 	defer func() {
 		if resp.Body != nil {
@@ -367,26 +477,9 @@ func deleteBucketLifecyclePolicy(ctx context.Context, client *GarageClient, buck
 		bucketName = aliases[0]
 	}
 
-	// Construct S3 endpoint URL
-	s3URL := fmt.Sprintf("%s://%s/%s?lifecycle",
-		client.Scheme,
-		replacePort(client.Host, 3900),
-		bucketName)
-
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "DELETE", s3URL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Use admin token for authorization
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.Token))
-
-	// Execute request
-	httpClient := &http.Client{}
-	resp, err = httpClient.Do(req)
+	resp, err = client.s3Request(ctx, http.MethodDelete, bucketName, "", lifecycleQuery, nil, nil)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return err
 	}
 	defer func() {
 		if resp.Body != nil {
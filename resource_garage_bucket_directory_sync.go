@@ -0,0 +1,586 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// matchGlob reports whether a slash-separated path matches pattern. Segments are matched
+// with path.Match, and a "**" segment matches any number of segments, including none.
+func matchGlob(pattern, name string) bool {
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchGlobSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func validateGlob(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	for _, segment := range strings.Split(value, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			errors = append(errors, fmt.Errorf("%q: invalid glob pattern %q: %w", k, value, err))
+			return
+		}
+	}
+	return
+}
+
+// directorySyncFilter selects the files of the source directory to synchronize.
+type directorySyncFilter struct {
+	include []string
+	exclude []string
+}
+
+// matches reports whether a path relative to the source directory is synchronized:
+// it must match an include pattern (if any) and no exclude pattern.
+func (f directorySyncFilter) matches(rel string) bool {
+	for _, pattern := range f.exclude {
+		if matchGlob(pattern, rel) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheControlRule sets the Cache-Control header of the files matching pattern.
+type cacheControlRule struct {
+	pattern string
+	value   string
+}
+
+// cacheControlFor returns the value of the first rule matching rel, or an empty string.
+func cacheControlFor(rules []cacheControlRule, rel string) string {
+	for _, rule := range rules {
+		if matchGlob(rule.pattern, rel) {
+			return rule.value
+		}
+	}
+	return ""
+}
+
+// syncFile is a local file to synchronize.
+type syncFile struct {
+	path         string
+	rel          string
+	key          string
+	size         int64
+	etag         string
+	contentType  string
+	cacheControl string
+}
+
+// directoryDigest returns a digest of the keys, content and headers of files.
+func directoryDigest(files []syncFile) string {
+	sorted := append([]syncFile(nil), files...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].key < sorted[j].key })
+
+	h := sha256.New()
+	for _, f := range sorted {
+		fmt.Fprintf(h, "%s\t%s\t%s\t%s\n", f.key, f.etag, f.contentType, f.cacheControl)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// syncPrefix normalizes a key prefix to either an empty string or a string ending with a slash.
+func syncPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
+
+// directorySync holds the configuration of a garage_bucket_directory_sync resource.
+type directorySync struct {
+	bucket    string
+	sourceDir string
+	prefix    string
+	filter    directorySyncFilter
+	rules     []cacheControlRule
+}
+
+func expandDirectorySync(d resourceGetter) directorySync {
+	return directorySync{
+		bucket:    d.Get("bucket").(string),
+		sourceDir: d.Get("source_dir").(string),
+		prefix:    syncPrefix(d.Get("prefix").(string)),
+		filter: directorySyncFilter{
+			include: expandStringList(d.Get("include").([]interface{})),
+			exclude: expandStringList(d.Get("exclude").([]interface{})),
+		},
+		rules: expandCacheControlRules(d.Get("cache_control").([]interface{})),
+	}
+}
+
+func expandCacheControlRules(raw []interface{}) []cacheControlRule {
+	rules := make([]cacheControlRule, 0, len(raw))
+	for _, v := range raw {
+		rule, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rules = append(rules, cacheControlRule{
+			pattern: rule["pattern"].(string),
+			value:   rule["value"].(string),
+		})
+	}
+	return rules
+}
+
+// scan walks the source directory and computes the ETag of every synchronized file.
+func (s directorySync) scan() ([]syncFile, error) {
+	var files []syncFile
+	err := filepath.WalkDir(s.sourceDir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		// Follow symlinks and skip anything that is not a regular file
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.sourceDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !s.filter.matches(rel) {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		etag, err := s3ETag(f, info.Size())
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", p, err)
+		}
+
+		files = append(files, syncFile{
+			path:         p,
+			rel:          rel,
+			key:          s.prefix + rel,
+			size:         info.Size(),
+			etag:         etag,
+			contentType:  guessContentType(rel),
+			cacheControl: cacheControlFor(s.rules, rel),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source directory: %w", err)
+	}
+	return files, nil
+}
+
+func syncFileETags(files []syncFile) map[string]interface{} {
+	etags := make(map[string]interface{}, len(files))
+	for _, f := range files {
+		etags[f.key] = f.etag
+	}
+	return etags
+}
+
+func resourceGarageBucketDirectorySync() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceGarageBucketDirectorySyncCreate,
+		ReadContext:   resourceGarageBucketDirectorySyncRead,
+		UpdateContext: resourceGarageBucketDirectorySyncUpdate,
+		DeleteContext: resourceGarageBucketDirectorySyncDelete,
+		CustomizeDiff: resourceGarageBucketDirectorySyncCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name (global alias) of the bucket",
+			},
+			"source_dir": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Path to the local directory to synchronize",
+			},
+			"prefix": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "",
+				Description: "Key prefix under which the files are uploaded (e.g., site/)",
+			},
+			"include": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateGlob},
+				Description: "Glob patterns of the files to synchronize, relative to source_dir. All files by default",
+			},
+			"exclude": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateGlob},
+				Description: "Glob patterns of the files to skip, relative to source_dir",
+			},
+			"cache_control": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Cache-Control header of the files matching a pattern. The first matching rule applies",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"pattern": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateGlob,
+							Description:  "Glob pattern of the files, relative to source_dir",
+						},
+						"value": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The Cache-Control header value",
+						},
+					},
+				},
+			},
+			"delete": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Also delete objects under the prefix that do not exist in source_dir, even if they were not uploaded by this resource",
+			},
+			"concurrency": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      8,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum number of concurrent uploads and deletions",
+			},
+			"files": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "ETags of the objects uploaded by this resource, by key",
+			},
+			"extraneous_keys": {
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "With delete, keys of the objects under the prefix that match the filters but were not uploaded by this resource. The next apply deletes those that do not exist in source_dir",
+			},
+			"content_digest": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA-256 digest of the keys, content and headers of the synchronized files",
+			},
+		},
+	}
+}
+
+// resourceGarageBucketDirectorySyncCustomizeDiff hashes the source directory so that local
+// changes, and remote changes detected by Read, show up as a diff on files.
+func resourceGarageBucketDirectorySyncCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	for _, k := range []string{"source_dir", "prefix", "include", "exclude", "cache_control"} {
+		if !d.NewValueKnown(k) {
+			if err := d.SetNewComputed("files"); err != nil {
+				return err
+			}
+			return d.SetNewComputed("content_digest")
+		}
+	}
+
+	files, err := expandDirectorySync(d).scan()
+	if err != nil {
+		return err
+	}
+
+	if old, _ := d.GetChange("files"); !reflect.DeepEqual(old, syncFileETags(files)) {
+		if err := d.SetNew("files", syncFileETags(files)); err != nil {
+			return err
+		}
+	}
+	if digest := directoryDigest(files); d.Get("content_digest").(string) != digest {
+		if err := d.SetNew("content_digest", digest); err != nil {
+			return err
+		}
+	}
+	if d.Get("delete").(bool) && d.Get("extraneous_keys").(*schema.Set).Len() > 0 {
+		return d.SetNewComputed("extraneous_keys")
+	}
+	return nil
+}
+
+func resourceGarageBucketDirectorySyncCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	cfg := expandDirectorySync(d)
+
+	// Every local file is uploaded, only remote objects need to be listed for deletion
+	var extraneous []string
+	if d.Get("delete").(bool) {
+		objects, err := client.listObjects(ctx, cfg.bucket, cfg.prefix)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("failed to list objects: %w", err))
+		}
+		extraneous = extraneousKeys(objects, nil, cfg)
+	}
+
+	// Set the ID first, so that the objects uploaded before a failure are kept in the state
+	// and deleted on destroy
+	d.SetId(cfg.bucket + "/" + cfg.prefix)
+
+	if diags := resourceGarageBucketDirectorySyncApply(ctx, d, client, cfg, map[string]string{}, extraneous, nil, true); diags.HasError() {
+		return diags
+	}
+
+	return resourceGarageBucketDirectorySyncRead(ctx, d, m)
+}
+
+func resourceGarageBucketDirectorySyncRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	cfg := expandDirectorySync(d)
+
+	objects, err := client.listObjects(ctx, cfg.bucket, cfg.prefix)
+	if err != nil {
+		var s3Err *s3Error
+		if errors.As(err, &s3Err) && s3Err.StatusCode == http.StatusNotFound {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(fmt.Errorf("failed to list objects: %w", err))
+	}
+
+	// Only the objects uploaded by this resource are tracked in files. With delete, the other
+	// objects are listed apart, so that the next apply removes them but destroy leaves them
+	managed := d.Get("files").(map[string]interface{})
+	files := make(map[string]interface{}, len(managed))
+	for _, object := range objects {
+		if _, ok := managed[object.Key]; ok {
+			files[object.Key] = object.ETag
+		}
+	}
+	var extraneous []string
+	if d.Get("delete").(bool) {
+		extraneous = extraneousKeys(objects, managed, cfg)
+	}
+
+	if err := d.Set("files", files); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("extraneous_keys", extraneous); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// extraneousKeys returns the keys of the objects that match the filters of cfg but are not
+// in managed.
+func extraneousKeys(objects []s3ListedObject, managed map[string]interface{}, cfg directorySync) []string {
+	var keys []string
+	for _, object := range objects {
+		if _, ok := managed[object.Key]; ok {
+			continue
+		}
+		if cfg.filter.matches(strings.TrimPrefix(object.Key, cfg.prefix)) {
+			keys = append(keys, object.Key)
+		}
+	}
+	return keys
+}
+
+func resourceGarageBucketDirectorySyncUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	cfg := expandDirectorySync(d)
+
+	oldFiles, _ := d.GetChange("files")
+	current := make(map[string]string)
+	for key, etag := range oldFiles.(map[string]interface{}) {
+		current[key] = etag.(string)
+	}
+	oldRules, _ := d.GetChange("cache_control")
+	var extraneous []string
+	if d.Get("delete").(bool) {
+		oldExtraneous, _ := d.GetChange("extraneous_keys")
+		extraneous = expandStringList(oldExtraneous.(*schema.Set).List())
+	}
+
+	if diags := resourceGarageBucketDirectorySyncApply(ctx, d, client, cfg, current, extraneous, expandCacheControlRules(oldRules.([]interface{})), false); diags.HasError() {
+		return diags
+	}
+
+	return resourceGarageBucketDirectorySyncRead(ctx, d, m)
+}
+
+func resourceGarageBucketDirectorySyncDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	bucket := d.Get("bucket").(string)
+
+	keys := make([]string, 0)
+	for key := range d.Get("files").(map[string]interface{}) {
+		keys = append(keys, key)
+	}
+
+	err := runConcurrently(ctx, len(keys), d.Get("concurrency").(int), func(ctx context.Context, i int) error {
+		if err := client.deleteObject(ctx, bucket, keys[i]); err != nil {
+			return fmt.Errorf("failed to delete object %s: %w", keys[i], err)
+		}
+		return nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceGarageBucketDirectorySyncApply uploads the local files whose content or Cache-Control
+// differs from current (the remote ETags by key), and deletes the keys of current and extraneous
+// that no longer exist locally. oldRules are the cache-control rules the current objects were
+// uploaded with, uploadAll is set when they are unknown.
+func resourceGarageBucketDirectorySyncApply(ctx context.Context, d *schema.ResourceData, client *GarageClient, cfg directorySync, current map[string]string, extraneous []string, oldRules []cacheControlRule, uploadAll bool) diag.Diagnostics {
+	files, err := cfg.scan()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var uploads []syncFile
+	local := make(map[string]bool, len(files))
+	for _, f := range files {
+		local[f.key] = true
+		etag, exists := current[f.key]
+		if uploadAll || !exists || etag != f.etag || cacheControlFor(oldRules, f.rel) != f.cacheControl {
+			uploads = append(uploads, f)
+		}
+	}
+	var deletions []string
+	for key := range current {
+		if !local[key] {
+			deletions = append(deletions, key)
+		}
+	}
+	for _, key := range extraneous {
+		if _, tracked := current[key]; !tracked && !local[key] {
+			deletions = append(deletions, key)
+		}
+	}
+
+	concurrency := d.Get("concurrency").(int)
+
+	var mu sync.Mutex
+	uploaded := make(map[string]interface{}, len(current))
+	for key, etag := range current {
+		uploaded[key] = etag
+	}
+	// Record what was uploaded and deleted even on failure, so the next plan only retries the rest
+	defer func() {
+		_ = d.Set("files", uploaded)
+	}()
+
+	err = runConcurrently(ctx, len(uploads), concurrency, func(ctx context.Context, i int) error {
+		f := uploads[i]
+		etag, err := uploadSyncFile(ctx, client, cfg.bucket, f)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		uploaded[f.key] = etag
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = runConcurrently(ctx, len(deletions), concurrency, func(ctx context.Context, i int) error {
+		if err := client.deleteObject(ctx, cfg.bucket, deletions[i]); err != nil {
+			return fmt.Errorf("failed to delete object %s: %w", deletions[i], err)
+		}
+		// Objects stay tracked until they are deleted, so that failed deletions are retried
+		mu.Lock()
+		delete(uploaded, deletions[i])
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("content_digest", directoryDigest(files)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func uploadSyncFile(ctx context.Context, client *GarageClient, bucket string, f syncFile) (string, error) {
+	content, err := os.Open(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", f.path, err)
+	}
+	defer func() {
+		_ = content.Close()
+	}()
+
+	header := http.Header{"Content-Type": {f.contentType}}
+	if f.cacheControl != "" {
+		header.Set("Cache-Control", f.cacheControl)
+	}
+
+	etag, err := client.uploadObject(ctx, bucket, f.key, content, f.size, header)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", f.key, err)
+	}
+	if etag != "" && etag != f.etag {
+		return "", fmt.Errorf("uploaded object %s has ETag %s, expected %s: the file changed during upload", f.key, etag, f.etag)
+	}
+	return f.etag, nil
+}
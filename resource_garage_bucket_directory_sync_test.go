@@ -0,0 +1,171 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"index.html", "index.html", true},
+		{"*.html", "index.html", true},
+		{"*.html", "blog/index.html", false},
+		{"**/*.html", "index.html", true},
+		{"**/*.html", "blog/2024/index.html", true},
+		{"**/*.html", "blog/app.css", false},
+		{"assets/**", "assets/css/app.css", true},
+		{"assets/**", "assets", true},
+		{"assets/**", "other/app.css", false},
+		{"assets/**/*.js", "assets/app.js", true},
+		{"assets/**/*.js", "assets/vendor/lib/app.js", true},
+		{"**", "any/path/at/all", true},
+		{"**/.*", "dir/.DS_Store", true},
+		{"img/?.png", "img/a.png", true},
+		{"img/?.png", "img/ab.png", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			if result := matchGlob(tt.pattern, tt.name); result != tt.expected {
+				t.Errorf("matchGlob(%q, %q) = %v, expected %v", tt.pattern, tt.name, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDirectorySyncFilterMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   directorySyncFilter
+		rel      string
+		expected bool
+	}{
+		{"no filter", directorySyncFilter{}, "index.html", true},
+		{"include match", directorySyncFilter{include: []string{"**/*.html"}}, "blog/index.html", true},
+		{"include mismatch", directorySyncFilter{include: []string{"**/*.html"}}, "app.css", false},
+		{"exclude match", directorySyncFilter{exclude: []string{"**/*.map"}}, "assets/app.js.map", false},
+		{"exclude wins over include", directorySyncFilter{include: []string{"assets/**"}, exclude: []string{"**/*.map"}}, "assets/app.js.map", false},
+		{"include with exclude", directorySyncFilter{include: []string{"assets/**"}, exclude: []string{"**/*.map"}}, "assets/app.js", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.filter.matches(tt.rel); result != tt.expected {
+				t.Errorf("matches(%q) = %v, expected %v", tt.rel, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCacheControlFor(t *testing.T) {
+	rules := []cacheControlRule{
+		{pattern: "**/*.html", value: "no-cache"},
+		{pattern: "assets/**", value: "max-age=31536000, immutable"},
+	}
+
+	tests := []struct {
+		rel      string
+		expected string
+	}{
+		{"index.html", "no-cache"},
+		{"assets/app.css", "max-age=31536000, immutable"},
+		{"assets/page.html", "no-cache"},
+		{"robots.txt", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			if result := cacheControlFor(rules, tt.rel); result != tt.expected {
+				t.Errorf("cacheControlFor(%q) = %q, expected %q", tt.rel, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDirectorySyncScan(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"index.html":         "hello",
+		"assets/app.css":     "",
+		"assets/app.css.map": "{}",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := directorySync{
+		sourceDir: dir,
+		prefix:    syncPrefix("/site"),
+		filter:    directorySyncFilter{exclude: []string{"**/*.map"}},
+		rules:     []cacheControlRule{{pattern: "**/*.html", value: "no-cache"}},
+	}
+	files, err := cfg.scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"site/index.html":     "5d41402abc4b2a76b9719d911017c592",
+		"site/assets/app.css": "d41d8cd98f00b204e9800998ecf8427e",
+	}
+	etags := syncFileETags(files)
+	if len(etags) != len(expected) {
+		t.Fatalf("scan() returned %v, expected %v", etags, expected)
+	}
+	for key, etag := range expected {
+		if etags[key] != etag {
+			t.Errorf("ETag of %s = %v, expected %v", key, etags[key], etag)
+		}
+	}
+
+	for _, f := range files {
+		if f.key == "site/index.html" && (f.cacheControl != "no-cache" || f.contentType != "text/html; charset=utf-8") {
+			t.Errorf("index.html headers = %q, %q", f.cacheControl, f.contentType)
+		}
+	}
+
+	// The digest covers headers, not only content
+	digest := directoryDigest(files)
+	files[0].cacheControl = "max-age=60"
+	if directoryDigest(files) == digest {
+		t.Errorf("directoryDigest() did not change with the Cache-Control header")
+	}
+}
+
+func TestExtraneousKeys(t *testing.T) {
+	cfg := directorySync{prefix: "site/", filter: directorySyncFilter{exclude: []string{"**/*.map"}}}
+	objects := []s3ListedObject{
+		{Key: "site/index.html"},
+		{Key: "site/old.html"},
+		{Key: "site/app.js.map"},
+	}
+
+	tests := []struct {
+		name     string
+		managed  map[string]interface{}
+		expected []string
+	}{
+		{"nothing uploaded", nil, []string{"site/index.html", "site/old.html"}},
+		{"uploaded objects", map[string]interface{}{"site/index.html": "etag"}, []string{"site/old.html"}},
+		{"everything uploaded", map[string]interface{}{"site/index.html": "etag", "site/old.html": "etag"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := extraneousKeys(objects, tt.managed, cfg); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("extraneousKeys() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
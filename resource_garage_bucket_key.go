@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 
-	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -16,6 +15,14 @@ func resourceGarageBucketKey() *schema.Resource {
 		ReadContext:   resourceGarageBucketKeyRead,
 		UpdateContext: resourceGarageBucketKeyUpdate,
 		DeleteContext: resourceGarageBucketKeyDelete,
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceGarageBucketKeyV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceGarageBucketKeyStateUpgradeV0,
+			},
+		},
 		Schema: map[string]*schema.Schema{
 			"bucket_id": {
 				Type:        schema.TypeString,
@@ -44,36 +51,89 @@ func resourceGarageBucketKey() *schema.Resource {
 				Required:    true,
 				Description: "Grant owner permission",
 			},
+			"revoke_all_on_destroy": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Revoke read, write and owner on destroy, including permissions this resource did not grant",
+			},
+			"granted_permissions": {
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Permissions granted by this resource, which are revoked on destroy",
+			},
 		},
 	}
 }
 
+// resourceGarageBucketKeyV0 is the schema before granted_permissions was recorded.
+func resourceGarageBucketKeyV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"bucket_id":     {Type: schema.TypeString, Required: true},
+			"access_key_id": {Type: schema.TypeString, Required: true},
+			"read":          {Type: schema.TypeBool, Required: true},
+			"write":         {Type: schema.TypeBool, Required: true},
+			"owner":         {Type: schema.TypeBool, Required: true},
+		},
+	}
+}
+
+// resourceGarageBucketKeyStateUpgradeV0 considers the flags set in the state as granted by the
+// resource, so that destroy keeps revoking them.
+func resourceGarageBucketKeyStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if rawState == nil {
+		return nil, nil
+	}
+	granted := []interface{}{}
+	for _, flag := range bucketPermFlags {
+		if v, ok := rawState[flag].(bool); ok && v {
+			granted = append(granted, flag)
+		}
+	}
+	rawState["granted_permissions"] = granted
+	rawState["revoke_all_on_destroy"] = false
+	return rawState, nil
+}
+
+// updateGrantedPerms returns the flags granted by the resource after going from current to
+// desired: the granted flags that are still desired, and the flags allowed by the change.
+func updateGrantedPerms(granted, current, desired bucketPerms) bucketPerms {
+	allowed, _ := diffBucketPerms(current, desired)
+	return bucketPerms{
+		Read:  (granted.Read && desired.Read) || allowed.Read,
+		Write: (granted.Write && desired.Write) || allowed.Write,
+		Owner: (granted.Owner && desired.Owner) || allowed.Owner,
+	}
+}
+
 func resourceGarageBucketKeyCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*GarageClient)
 	bucketID := d.Get("bucket_id").(string)
 	keyID := d.Get("access_key_id").(string)
-	read := d.Get("read").(bool)
-	write := d.Get("write").(bool)
-	owner := d.Get("owner").(bool)
-
-	perms := garage.NewApiBucketKeyPerm()
-	perms.SetRead(read)
-	perms.SetWrite(write)
-	perms.SetOwner(owner)
-
-	updateReq := garage.NewBucketKeyPermChangeRequest(keyID, bucketID, *perms)
+	desired := bucketPerms{
+		Read:  d.Get("read").(bool),
+		Write: d.Get("write").(bool),
+		Owner: d.Get("owner").(bool),
+	}
 
-	_, resp, err := client.Client.PermissionAPI.AllowBucketKey(client.WithAuth(ctx)).Body(*updateReq).Execute()
+	// Only grant, and later revoke, the flags the key does not have yet
+	perms, err := getKeyBucketPerms(ctx, client, keyID)
 	if err != nil {
-		return diag.FromErr(fmt.Errorf("failed to update bucket key permissions: %w", err))
+		return diag.FromErr(err)
 	}
-	defer func() {
-		if resp.Body != nil {
-			_ = resp.Body.Close()
+	granted, _ := diffBucketPerms(perms[bucketID], desired)
+	if granted.any() {
+		if err := allowBucketKey(ctx, client, keyID, bucketID, granted.Read, granted.Write, granted.Owner); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to update bucket key permissions: %w", err))
 		}
-	}()
+	}
 
 	d.SetId(fmt.Sprintf("%s/%s", bucketID, keyID))
+	if err := d.Set("granted_permissions", granted.flags()); err != nil {
+		return diag.FromErr(err)
+	}
 
 	return resourceGarageBucketKeyRead(ctx, d, m)
 }
@@ -116,7 +176,11 @@ func resourceGarageBucketKeyRead(ctx context.Context, d *schema.ResourceData, m
 	}
 
 	// Key doesn't have permissions on this bucket
-	d.SetId("")
+	for _, flag := range bucketPermFlags {
+		if err := d.Set(flag, false); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	return nil
 }
 
@@ -124,26 +188,34 @@ func resourceGarageBucketKeyUpdate(ctx context.Context, d *schema.ResourceData,
 	client := m.(*GarageClient)
 	bucketID := d.Get("bucket_id").(string)
 	keyID := d.Get("access_key_id").(string)
-	read := d.Get("read").(bool)
-	write := d.Get("write").(bool)
-	owner := d.Get("owner").(bool)
-
-	perms := garage.NewApiBucketKeyPerm()
-	perms.SetRead(read)
-	perms.SetWrite(write)
-	perms.SetOwner(owner)
 
-	updateReq := garage.NewBucketKeyPermChangeRequest(keyID, bucketID, *perms)
+	desired := bucketPerms{
+		Read:  d.Get("read").(bool),
+		Write: d.Get("write").(bool),
+		Owner: d.Get("owner").(bool),
+	}
 
-	_, resp, err := client.Client.PermissionAPI.AllowBucketKey(client.WithAuth(ctx)).Body(*updateReq).Execute()
+	// AllowBucketKey only adds flags, removed flags must be denied. Compare with Garage rather
+	// than the state, which may be stale.
+	perms, err := getKeyBucketPerms(ctx, client, keyID)
 	if err != nil {
-		return diag.FromErr(fmt.Errorf("failed to update bucket key permissions: %w", err))
+		return diag.FromErr(err)
 	}
-	defer func() {
-		if resp.Body != nil {
-			_ = resp.Body.Close()
+	current := perms[bucketID]
+
+	if current != desired {
+		if err := setBucketPerms(ctx, client, keyID, bucketID, current, desired); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to update bucket key permissions: %w", err))
 		}
-	}()
+		if err := verifyBucketPerms(ctx, client, keyID, bucketID, desired); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to update bucket key permissions: %w", err))
+		}
+
+		granted := bucketPermsFromFlags(d.Get("granted_permissions").(*schema.Set).List())
+		if err := d.Set("granted_permissions", updateGrantedPerms(granted, current, desired).flags()); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 
 	return resourceGarageBucketKeyRead(ctx, d, m)
 }
@@ -153,23 +225,16 @@ func resourceGarageBucketKeyDelete(ctx context.Context, d *schema.ResourceData,
 	bucketID := d.Get("bucket_id").(string)
 	keyID := d.Get("access_key_id").(string)
 
-	// Remove all permissions by denying them
-	perms := garage.NewApiBucketKeyPerm()
-	perms.SetRead(true)
-	perms.SetWrite(true)
-	perms.SetOwner(true)
-
-	updateReq := garage.NewBucketKeyPermChangeRequest(keyID, bucketID, *perms)
-
-	_, resp, err := client.Client.PermissionAPI.DenyBucketKey(client.WithAuth(ctx)).Body(*updateReq).Execute()
-	if err != nil {
-		return diag.FromErr(fmt.Errorf("failed to remove bucket key permissions: %w", err))
+	// Permissions granted by hand or by another workspace are left in place
+	revoked := bucketPermsFromFlags(d.Get("granted_permissions").(*schema.Set).List())
+	if d.Get("revoke_all_on_destroy").(bool) {
+		revoked = bucketPerms{Read: true, Write: true, Owner: true}
 	}
-	defer func() {
-		if resp.Body != nil {
-			_ = resp.Body.Close()
+	if revoked.any() {
+		if err := denyBucketKey(ctx, client, keyID, bucketID, revoked); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to remove bucket key permissions: %w", err))
 		}
-	}()
+	}
 
 	d.SetId("")
 	return nil
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateGrantedPerms(t *testing.T) {
+	for _, granted := range allBucketPerms() {
+		for _, current := range allBucketPerms() {
+			// The resource only records flags that the key has
+			if (granted.Read && !current.Read) || (granted.Write && !current.Write) || (granted.Owner && !current.Owner) {
+				continue
+			}
+			for _, desired := range allBucketPerms() {
+				t.Run(fmt.Sprintf("granted %s, %s to %s", granted, current, desired), func(t *testing.T) {
+					result := updateGrantedPerms(granted, current, desired)
+					if (result.Read && !desired.Read) || (result.Write && !desired.Write) || (result.Owner && !desired.Owner) {
+						t.Errorf("granted %s includes flags that are not desired", result)
+					}
+					allowed, _ := diffBucketPerms(current, desired)
+					if (allowed.Read && !result.Read) || (allowed.Write && !result.Write) || (allowed.Owner && !result.Owner) {
+						t.Errorf("granted %s misses allowed flags %s", result, allowed)
+					}
+					kept := bucketPerms{Read: granted.Read && desired.Read, Write: granted.Write && desired.Write, Owner: granted.Owner && desired.Owner}
+					if (kept.Read && !result.Read) || (kept.Write && !result.Write) || (kept.Owner && !result.Owner) {
+						t.Errorf("granted %s misses previously granted flags %s", result, kept)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestResourceGarageBucketKeyStateUpgradeV0(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    map[string]interface{}
+		expected []interface{}
+	}{
+		{"read write", map[string]interface{}{"read": true, "write": true, "owner": false}, []interface{}{"read", "write"}},
+		{"owner", map[string]interface{}{"read": false, "write": false, "owner": true}, []interface{}{"owner"}},
+		{"none", map[string]interface{}{"read": false, "write": false, "owner": false}, []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := resourceGarageBucketKeyStateUpgradeV0(context.Background(), tt.state, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result["granted_permissions"], tt.expected) {
+				t.Errorf("granted_permissions = %v, expected %v", result["granted_permissions"], tt.expected)
+			}
+			if result["revoke_all_on_destroy"] != false {
+				t.Errorf("revoke_all_on_destroy = %v, expected false", result["revoke_all_on_destroy"])
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestResourceGarageBucketLocalAliasDiff(t *testing.T) {
+	localAlias := []interface{}{
+		map[string]interface{}{
+			"access_key_id": "GK31c2f218a2e44f485b94239e",
+			"alias":         "uploads",
+			"read":          true,
+			"write":         true,
+		},
+	}
+	existing := &terraform.InstanceState{
+		ID: "b1",
+		Attributes: map[string]string{
+			"id":           "b1",
+			"global_alias": "data",
+		},
+	}
+	existingWithAlias := &terraform.InstanceState{
+		ID: "b1",
+		Attributes: map[string]string{
+			"id":                          "b1",
+			"global_alias":                "data",
+			"local_alias.#":               "1",
+			"local_alias.0.access_key_id": "GK31c2f218a2e44f485b94239e",
+			"local_alias.0.alias":         "uploads",
+			"local_alias.0.read":          "true",
+			"local_alias.0.write":         "true",
+			"local_alias.0.owner":         "false",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		state      *terraform.InstanceState
+		config     map[string]interface{}
+		expectDiff bool
+	}{
+		{"new bucket", nil, map[string]interface{}{"global_alias": "data", "local_alias": localAlias}, true},
+		{"imported bucket", existing, map[string]interface{}{"global_alias": "data", "local_alias": localAlias}, false},
+		{"block removed", existingWithAlias, map[string]interface{}{"global_alias": "data"}, false},
+		{"alias changed", existingWithAlias, map[string]interface{}{"global_alias": "data", "local_alias": []interface{}{
+			map[string]interface{}{"access_key_id": "GK31c2f218a2e44f485b94239e", "alias": "other", "read": true},
+		}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := resourceGarageBucket().Diff(context.Background(), tt.state, terraform.NewResourceConfigRaw(tt.config), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			hasDiff := diff != nil && !diff.Empty()
+			if hasDiff != tt.expectDiff {
+				t.Errorf("diff = %v, expected a diff: %v", diff, tt.expectDiff)
+			}
+			if diff != nil && diff.RequiresNew() {
+				t.Errorf("local_alias must not force a new bucket: %v", diff)
+			}
+		})
+	}
+}
+
+func TestResourceGarageBucketExpirationDaysCredentials(t *testing.T) {
+	signed := &GarageClient{S3AccessKeyID: "GK31c2f218a2e44f485b94239e", S3SecretAccessKey: "secret"}
+
+	tests := []struct {
+		name     string
+		client   *GarageClient
+		config   map[string]interface{}
+		hasError bool
+	}{
+		{"without expiration", &GarageClient{}, map[string]interface{}{"global_alias": "data"}, false},
+		{"without S3 credentials", &GarageClient{}, map[string]interface{}{"global_alias": "data", "expiration_days": 30}, true},
+		{"with S3 credentials", signed, map[string]interface{}{"global_alias": "data", "expiration_days": 30}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resourceGarageBucket().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(tt.config), tt.client)
+			if tt.hasError != (err != nil) {
+				t.Errorf("Diff() error = %v, expected error: %v", err, tt.hasError)
+			}
+		})
+	}
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// WebsiteConfiguration is the body of the S3 PutBucketWebsite and GetBucketWebsite requests
+type WebsiteConfiguration struct {
+	XMLName               xml.Name               `xml:"WebsiteConfiguration"`
+	Xmlns                 string                 `xml:"xmlns,attr,omitempty"`
+	IndexDocument         *IndexDocument         `xml:"IndexDocument,omitempty"`
+	ErrorDocument         *ErrorDocument         `xml:"ErrorDocument,omitempty"`
+	RedirectAllRequestsTo *RedirectAllRequestsTo `xml:"RedirectAllRequestsTo,omitempty"`
+	RoutingRules          []RoutingRule          `xml:"RoutingRules>RoutingRule,omitempty"`
+}
+
+type IndexDocument struct {
+	Suffix string `xml:"Suffix"`
+}
+
+type ErrorDocument struct {
+	Key string `xml:"Key"`
+}
+
+type RedirectAllRequestsTo struct {
+	HostName string `xml:"HostName"`
+	Protocol string `xml:"Protocol,omitempty"`
+}
+
+type RoutingRule struct {
+	Condition *RoutingRuleCondition `xml:"Condition,omitempty"`
+	Redirect  RoutingRuleRedirect   `xml:"Redirect"`
+}
+
+type RoutingRuleCondition struct {
+	HttpErrorCodeReturnedEquals string `xml:"HttpErrorCodeReturnedEquals,omitempty"`
+	KeyPrefixEquals             string `xml:"KeyPrefixEquals,omitempty"`
+}
+
+type RoutingRuleRedirect struct {
+	HostName             string `xml:"HostName,omitempty"`
+	HttpRedirectCode     string `xml:"HttpRedirectCode,omitempty"`
+	Protocol             string `xml:"Protocol,omitempty"`
+	ReplaceKeyPrefixWith string `xml:"ReplaceKeyPrefixWith,omitempty"`
+	ReplaceKeyWith       string `xml:"ReplaceKeyWith,omitempty"`
+}
+
+// websiteQuery selects the website subresource of a bucket
+var websiteQuery = url.Values{"website": {""}}
+
+func resourceGarageBucketWebsiteConfiguration() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceGarageBucketWebsiteConfigurationCreate,
+		ReadContext:   resourceGarageBucketWebsiteConfigurationRead,
+		UpdateContext: resourceGarageBucketWebsiteConfigurationUpdate,
+		DeleteContext: resourceGarageBucketWebsiteConfigurationDelete,
+		CustomizeDiff: resourceGarageBucketWebsiteConfigurationCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name (global alias) of the bucket",
+			},
+			"domains": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Domains that must be served by this bucket. Checked at plan time with the Garage CheckDomain endpoint",
+			},
+			"index_document": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"redirect_all_requests_to"},
+				Description:   "Document returned for requests to a directory",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"suffix": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Suffix appended to requests for a directory (e.g., index.html)",
+						},
+					},
+				},
+			},
+			"error_document": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"redirect_all_requests_to"},
+				Description:   "Document returned when an error occurs",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Key of the object returned when an error occurs (e.g., 404.html)",
+						},
+					},
+				},
+			},
+			"redirect_all_requests_to": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"index_document", "error_document", "routing_rule"},
+				Description:   "Redirect every request to another host, e.g. from an apex domain to www",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"host_name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Host name requests are redirected to",
+						},
+						"protocol": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice([]string{"http", "https"}, false),
+							Description:  "Protocol of the redirect (http or https). Defaults to the protocol of the request",
+						},
+					},
+				},
+			},
+			"routing_rule": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				ConflictsWith: []string{"redirect_all_requests_to"},
+				Description:   "Redirect rules, evaluated in order",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"condition": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							MaxItems:    1,
+							Description: "Condition of the rule. Without condition the rule applies to every request",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"http_error_code_returned_equals": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "Apply the rule when the request fails with this HTTP error code (e.g., 404)",
+									},
+									"key_prefix_equals": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "Apply the rule to keys starting with this prefix",
+									},
+								},
+							},
+						},
+						"redirect": {
+							Type:        schema.TypeList,
+							Required:    true,
+							MaxItems:    1,
+							Description: "Redirect returned when the rule applies",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"host_name": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "Host name of the redirect. Defaults to the host of the request",
+									},
+									"http_redirect_code": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "HTTP status code of the redirect (e.g., 301)",
+									},
+									"protocol": {
+										Type:         schema.TypeString,
+										Optional:     true,
+										ValidateFunc: validation.StringInSlice([]string{"http", "https"}, false),
+										Description:  "Protocol of the redirect (http or https)",
+									},
+									"replace_key_prefix_with": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "Replace the key_prefix_equals part of the key with this prefix",
+									},
+									"replace_key_with": {
+										Type:        schema.TypeString,
+										Optional:    true,
+										Description: "Replace the whole key with this key",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// expandWebsiteConfiguration builds the WebsiteConfiguration XML body from the resource configuration.
+func expandWebsiteConfiguration(d resourceGetter) WebsiteConfiguration {
+	config := WebsiteConfiguration{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/"}
+
+	if v := d.Get("index_document").([]interface{}); len(v) > 0 && v[0] != nil {
+		config.IndexDocument = &IndexDocument{Suffix: v[0].(map[string]interface{})["suffix"].(string)}
+	}
+	if v := d.Get("error_document").([]interface{}); len(v) > 0 && v[0] != nil {
+		config.ErrorDocument = &ErrorDocument{Key: v[0].(map[string]interface{})["key"].(string)}
+	}
+	if v := d.Get("redirect_all_requests_to").([]interface{}); len(v) > 0 && v[0] != nil {
+		redirect := v[0].(map[string]interface{})
+		config.RedirectAllRequestsTo = &RedirectAllRequestsTo{
+			HostName: redirect["host_name"].(string),
+			Protocol: redirect["protocol"].(string),
+		}
+	}
+
+	for _, v := range d.Get("routing_rule").([]interface{}) {
+		raw, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var rule RoutingRule
+		if c := raw["condition"].([]interface{}); len(c) > 0 && c[0] != nil {
+			condition := c[0].(map[string]interface{})
+			rule.Condition = &RoutingRuleCondition{
+				HttpErrorCodeReturnedEquals: condition["http_error_code_returned_equals"].(string),
+				KeyPrefixEquals:             condition["key_prefix_equals"].(string),
+			}
+		}
+		if r := raw["redirect"].([]interface{}); len(r) > 0 && r[0] != nil {
+			redirect := r[0].(map[string]interface{})
+			rule.Redirect = RoutingRuleRedirect{
+				HostName:             redirect["host_name"].(string),
+				HttpRedirectCode:     redirect["http_redirect_code"].(string),
+				Protocol:             redirect["protocol"].(string),
+				ReplaceKeyPrefixWith: redirect["replace_key_prefix_with"].(string),
+				ReplaceKeyWith:       redirect["replace_key_with"].(string),
+			}
+		}
+		config.RoutingRules = append(config.RoutingRules, rule)
+	}
+
+	return config
+}
+
+// flattenWebsiteConfiguration converts a WebsiteConfiguration to the attributes of the resource.
+func flattenWebsiteConfiguration(config WebsiteConfiguration) map[string]interface{} {
+	attrs := map[string]interface{}{
+		"index_document":           []interface{}{},
+		"error_document":           []interface{}{},
+		"redirect_all_requests_to": []interface{}{},
+		"routing_rule":             []interface{}{},
+	}
+
+	if config.IndexDocument != nil {
+		attrs["index_document"] = []interface{}{map[string]interface{}{"suffix": config.IndexDocument.Suffix}}
+	}
+	if config.ErrorDocument != nil {
+		attrs["error_document"] = []interface{}{map[string]interface{}{"key": config.ErrorDocument.Key}}
+	}
+	if config.RedirectAllRequestsTo != nil {
+		attrs["redirect_all_requests_to"] = []interface{}{map[string]interface{}{
+			"host_name": config.RedirectAllRequestsTo.HostName,
+			"protocol":  config.RedirectAllRequestsTo.Protocol,
+		}}
+	}
+
+	rules := make([]interface{}, 0, len(config.RoutingRules))
+	for _, rule := range config.RoutingRules {
+		raw := map[string]interface{}{
+			"condition": []interface{}{},
+			"redirect": []interface{}{map[string]interface{}{
+				"host_name":               rule.Redirect.HostName,
+				"http_redirect_code":      rule.Redirect.HttpRedirectCode,
+				"protocol":                rule.Redirect.Protocol,
+				"replace_key_prefix_with": rule.Redirect.ReplaceKeyPrefixWith,
+				"replace_key_with":        rule.Redirect.ReplaceKeyWith,
+			}},
+		}
+		if rule.Condition != nil {
+			raw["condition"] = []interface{}{map[string]interface{}{
+				"http_error_code_returned_equals": rule.Condition.HttpErrorCodeReturnedEquals,
+				"key_prefix_equals":               rule.Condition.KeyPrefixEquals,
+			}}
+		}
+		rules = append(rules, raw)
+	}
+	attrs["routing_rule"] = rules
+
+	return attrs
+}
+
+// websiteConfigurationError explains S3 errors caused by parts of the website configuration
+// that Garage does not implement.
+func websiteConfigurationError(config WebsiteConfiguration, err error) error {
+	var s3Err *s3Error
+	if !errors.As(err, &s3Err) {
+		return err
+	}
+	if s3Err.StatusCode != http.StatusNotImplemented && s3Err.Code != "NotImplemented" &&
+		s3Err.Code != "MalformedXML" && s3Err.Code != "InvalidRequest" {
+		return err
+	}
+
+	var features []string
+	if config.RedirectAllRequestsTo != nil {
+		features = append(features, "redirect_all_requests_to")
+	}
+	if len(config.RoutingRules) > 0 {
+		features = append(features, "routing_rule")
+	}
+	if len(features) == 0 {
+		return err
+	}
+
+	return fmt.Errorf("garage rejected the website configuration, it uses %s which this Garage version does not support: %w",
+		strings.Join(features, " and "), err)
+}
+
+// resourceGarageBucketWebsiteConfigurationCustomizeDiff checks that the provider can sign S3 requests,
+// that every domain maps to the bucket and, once the website configuration exists, that Garage serves it.
+func resourceGarageBucketWebsiteConfigurationCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	client, ok := m.(*GarageClient)
+	if !ok {
+		return nil
+	}
+	if !client.hasS3Credentials() {
+		return errMissingS3Credentials
+	}
+	if !d.NewValueKnown("domains") || !d.NewValueKnown("bucket") {
+		return nil
+	}
+
+	bucket := d.Get("bucket").(string)
+	for _, domain := range expandStringList(d.Get("domains").([]interface{})) {
+		if alias := websiteDomainBucket(domain, client.S3RootDomain, client.WebRootDomain); alias != bucket {
+			return fmt.Errorf("domain %q is served by the bucket with global alias %q, not %q", domain, alias, bucket)
+		}
+
+		// Website access is only enabled by the first apply
+		if d.Id() == "" {
+			continue
+		}
+		enabled, err := checkWebsiteDomain(ctx, client, domain)
+		if err != nil {
+			return err
+		}
+		if !enabled {
+			return fmt.Errorf("garage does not serve domain %q as a website: check that bucket %q exists and has website access enabled", domain, bucket)
+		}
+	}
+
+	return nil
+}
+
+// checkWebsiteDomains warns about the domains that Garage does not serve after an apply.
+func checkWebsiteDomains(ctx context.Context, client *GarageClient, d *schema.ResourceData) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, domain := range expandStringList(d.Get("domains").([]interface{})) {
+		enabled, err := checkWebsiteDomain(ctx, client, domain)
+		if err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+		if !enabled {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Website domain not served",
+				Detail:   fmt.Sprintf("Garage does not serve domain %q as a website yet.", domain),
+			})
+		}
+	}
+	return diags
+}
+
+func resourceGarageBucketWebsiteConfigurationCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	bucket := d.Get("bucket").(string)
+
+	if err := putBucketWebsite(ctx, client, bucket, expandWebsiteConfiguration(d)); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to set website configuration: %w", err))
+	}
+
+	d.SetId(bucket)
+
+	diags := resourceGarageBucketWebsiteConfigurationRead(ctx, d, m)
+	if diags.HasError() {
+		return diags
+	}
+	return append(diags, checkWebsiteDomains(ctx, client, d)...)
+}
+
+func resourceGarageBucketWebsiteConfigurationRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	bucket := d.Id()
+
+	config, err := getBucketWebsite(ctx, client, bucket)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to read website configuration: %w", err))
+	}
+	if config == nil {
+		d.SetId("")
+		return nil
+	}
+
+	if err := d.Set("bucket", bucket); err != nil {
+		return diag.FromErr(err)
+	}
+	for k, v := range flattenWebsiteConfiguration(*config) {
+		if err := d.Set(k, v); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return nil
+}
+
+func resourceGarageBucketWebsiteConfigurationUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+
+	if err := putBucketWebsite(ctx, client, d.Id(), expandWebsiteConfiguration(d)); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to update website configuration: %w", err))
+	}
+
+	diags := resourceGarageBucketWebsiteConfigurationRead(ctx, d, m)
+	if diags.HasError() {
+		return diags
+	}
+	return append(diags, checkWebsiteDomains(ctx, client, d)...)
+}
+
+func resourceGarageBucketWebsiteConfigurationDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+
+	resp, err := client.s3Request(ctx, http.MethodDelete, d.Id(), "", websiteQuery, nil, nil)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to delete website configuration: %w", err))
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	if resp.StatusCode != http.StatusNotFound {
+		if err := checkS3Response(resp); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to delete website configuration: %w", err))
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// putBucketWebsite sets the website configuration of a bucket using the S3-compatible API
+func putBucketWebsite(ctx context.Context, client *GarageClient, bucket string, config WebsiteConfiguration) error {
+	xmlData, err := xml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal website configuration: %w", err)
+	}
+
+	resp, err := client.s3Request(ctx, http.MethodPut, bucket, "", websiteQuery, xmlData,
+		http.Header{"Content-Type": {"application/xml"}})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	return websiteConfigurationError(config, checkS3Response(resp))
+}
+
+// getBucketWebsite retrieves the website configuration of a bucket, or nil if the bucket
+// does not exist or has no website configuration
+func getBucketWebsite(ctx context.Context, client *GarageClient, bucket string) (*WebsiteConfiguration, error) {
+	resp, err := client.s3Request(ctx, http.MethodGet, bucket, "", websiteQuery, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := checkS3Response(resp); err != nil {
+		return nil, err
+	}
+
+	var config WebsiteConfiguration
+	if err := xml.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &config, nil
+}
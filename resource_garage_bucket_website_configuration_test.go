@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestExpandWebsiteConfiguration(t *testing.T) {
+	raw := map[string]interface{}{
+		"bucket":         "www-example-com",
+		"index_document": []interface{}{map[string]interface{}{"suffix": "index.html"}},
+		"error_document": []interface{}{map[string]interface{}{"key": "404.html"}},
+		"routing_rule": []interface{}{
+			map[string]interface{}{
+				"condition": []interface{}{map[string]interface{}{"key_prefix_equals": "docs/"}},
+				"redirect": []interface{}{map[string]interface{}{
+					"replace_key_prefix_with": "documentation/",
+					"http_redirect_code":      "301",
+				}},
+			},
+		},
+	}
+	d := schema.TestResourceDataRaw(t, resourceGarageBucketWebsiteConfiguration().Schema, raw)
+
+	body, err := xml.Marshal(expandWebsiteConfiguration(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<WebsiteConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+		`<IndexDocument><Suffix>index.html</Suffix></IndexDocument>` +
+		`<ErrorDocument><Key>404.html</Key></ErrorDocument>` +
+		`<RoutingRules><RoutingRule>` +
+		`<Condition><KeyPrefixEquals>docs/</KeyPrefixEquals></Condition>` +
+		`<Redirect><HttpRedirectCode>301</HttpRedirectCode><ReplaceKeyPrefixWith>documentation/</ReplaceKeyPrefixWith></Redirect>` +
+		`</RoutingRule></RoutingRules>` +
+		`</WebsiteConfiguration>`
+	if string(body) != expected {
+		t.Errorf("marshalled configuration = %s, expected %s", body, expected)
+	}
+}
+
+func TestFlattenWebsiteConfiguration(t *testing.T) {
+	// GetBucketWebsite response with the S3 namespace and a redirect-all configuration
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<WebsiteConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <RedirectAllRequestsTo><HostName>www.example.com</HostName><Protocol>https</Protocol></RedirectAllRequestsTo>
+</WebsiteConfiguration>`
+
+	var config WebsiteConfiguration
+	if err := xml.Unmarshal([]byte(body), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	attrs := flattenWebsiteConfiguration(config)
+	expected := []interface{}{map[string]interface{}{"host_name": "www.example.com", "protocol": "https"}}
+	if !reflect.DeepEqual(attrs["redirect_all_requests_to"], expected) {
+		t.Errorf("redirect_all_requests_to = %v, expected %v", attrs["redirect_all_requests_to"], expected)
+	}
+	if rules := attrs["routing_rule"].([]interface{}); len(rules) != 0 {
+		t.Errorf("routing_rule = %v, expected none", rules)
+	}
+
+	// Reading the configuration back must produce the same XML
+	d := schema.TestResourceDataRaw(t, resourceGarageBucketWebsiteConfiguration().Schema, map[string]interface{}{})
+	for k, v := range attrs {
+		if err := d.Set(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if roundTrip := expandWebsiteConfiguration(d); !reflect.DeepEqual(roundTrip.RedirectAllRequestsTo, config.RedirectAllRequestsTo) {
+		t.Errorf("round trip = %+v, expected %+v", roundTrip.RedirectAllRequestsTo, config.RedirectAllRequestsTo)
+	}
+}
+
+func TestWebsiteConfigurationError(t *testing.T) {
+	withRules := WebsiteConfiguration{RoutingRules: []RoutingRule{{Redirect: RoutingRuleRedirect{ReplaceKeyWith: "index.html"}}}}
+	notImplemented := &s3Error{StatusCode: http.StatusNotImplemented, Code: "NotImplemented", Message: "not implemented"}
+
+	tests := []struct {
+		name     string
+		config   WebsiteConfiguration
+		err      error
+		contains string
+	}{
+		{"no error", withRules, nil, ""},
+		{"not implemented routing rules", withRules, notImplemented, "uses routing_rule"},
+		{"not implemented without advanced features", WebsiteConfiguration{}, notImplemented, "NotImplemented"},
+		{"other error", withRules, &s3Error{StatusCode: http.StatusForbidden, Code: "AccessDenied"}, "AccessDenied"},
+		{"network error", withRules, fmt.Errorf("connection refused"), "connection refused"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := websiteConfigurationError(tt.config, tt.err)
+			if tt.contains == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("websiteConfigurationError() = %v, expected it to contain %q", err, tt.contains)
+			}
+		})
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -16,32 +20,158 @@ func resourceGarageKey() *schema.Resource {
 		ReadContext:   resourceGarageKeyRead,
 		UpdateContext: resourceGarageKeyUpdate,
 		DeleteContext: resourceGarageKeyDelete,
+		CustomizeDiff: resourceGarageKeyCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceGarageKeyImportState,
+		},
+		ValidateRawResourceConfigFuncs: []schema.ValidateRawResourceConfigFunc{
+			validateKeyImportSecret,
+		},
 		Schema: map[string]*schema.Schema{
 			"name": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The name of the access key",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"name_prefix"},
+				Description:   "The name of the access key. If omitted, a unique name is generated, beginning with name_prefix if set",
+			},
+			"name_prefix": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"name"},
+				Description:   "Creates a unique name beginning with this prefix",
 			},
 			"access_key_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateAccessKeyID,
+				Description:  "The access key ID. Set together with secret_access_key or secret_access_key_wo to import existing credentials, otherwise generated by Garage",
+			},
+			"secret_access_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"access_key_id"},
+				ValidateFunc: validateSecretAccessKey,
+				Description:  "The secret access key (only available on create). Set together with access_key_id to import existing credentials",
+			},
+			"secret_access_key_wo": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				WriteOnly:     true,
+				RequiredWith:  []string{"access_key_id"},
+				ConflictsWith: []string{"secret_access_key", "fetch_secret"},
+				ValidateFunc:  validateSecretAccessKey,
+				Description:   "Write-only secret access key to import with access_key_id. It is sent to Garage but never stored in the plan or state. Requires Terraform 1.11 or later",
+			},
+			"secret_access_key_wo_version": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{"secret_access_key_wo"},
+				Description:  "Version of secret_access_key_wo. Changing it imports the key again with the new secret",
+			},
+			"pgp_key": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// Forces a new key in CustomizeDiff, only when the state holds a secret
+				ValidateFunc:  validatePGPKey,
+				ConflictsWith: []string{"secret_access_key"},
+				Description:   "ASCII-armored PGP public key. When set, only the encrypted secret access key is stored in the state",
+			},
+			"fetch_secret": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Fetch the secret access key from Garage when it is not in the state, e.g. after an import. Import with an ID of the form <access_key_id>,fetch_secret to fetch it during the import",
+			},
+			"encrypted_secret": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "The access key ID",
+				Description: "The secret access key encrypted with pgp_key, base64-encoded",
 			},
-			"secret_access_key": {
+			"key_fingerprint": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Sensitive:   true,
-				Description: "The secret access key (only available on create)",
+				Description: "Fingerprint of the PGP key used to encrypt the secret access key",
+			},
+			"allow_create_bucket": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Allow the key to create buckets through the S3 API",
 			},
+			"expiration": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     validateRFC3339,
+				DiffSuppressFunc: suppressEquivalentTime,
+				ConflictsWith:    []string{"expires_in", "never_expires"},
+				Description:      "Expiration time (RFC3339 format)",
+			},
+			"expires_in": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ValidateFunc:  validateDuration,
+				ConflictsWith: []string{"expiration", "never_expires"},
+				Description:   "Validity of the key as a duration (e.g., 720h), counted from creation or from the last change of expires_in",
+			},
+			"never_expires": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Set the key to never expire. Turning it off requires expiration or expires_in",
+			},
+			"rotate_before_expiry": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// A fixed expiration would be given again to the replacement, and an imported
+				// access key ID cannot be imported twice
+				RequiredWith:  []string{"expires_in"},
+				ConflictsWith: []string{"access_key_id"},
+				ValidateFunc:  validateDuration,
+				Description:   "Plan the replacement of the key when it expires within this duration (e.g., 168h). Requires expires_in and conflicts with access_key_id. Use with create_before_destroy",
+			},
+			"ready_for_rotation": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the key expires within rotate_before_expiry and will be replaced",
+			},
+			"expired": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether this key is expired",
+			},
+			"created": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Creation date",
+			},
+			"buckets": keyBucketsSchema(),
 		},
 	}
 }
 
 func resourceGarageKeyCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*GarageClient)
-	name := d.Get("name").(string)
+	name := resourceName(d)
+	if err := checkKeyNameAvailable(ctx, client, name); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if _, ok := d.GetOk("access_key_id"); ok {
+		return resourceGarageKeyImport(ctx, d, m, name)
+	}
 
-	keyReq := garage.NewUpdateKeyRequestBody()
+	keyReq, err := expandKeyOptions(d, time.Now())
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	keyReq.SetName(name)
 
 	key, resp, err := client.Client.AccessKeyAPI.CreateKey(client.WithAuth(ctx)).Body(*keyReq).Execute()
@@ -59,11 +189,292 @@ func resourceGarageKeyCreate(ctx context.Context, d *schema.ResourceData, m inte
 		return diag.FromErr(err)
 	}
 	if secret, ok := key.GetSecretAccessKeyOk(); ok && secret != nil {
-		if err := d.Set("secret_access_key", *secret); err != nil {
+		if err := setSecret(d, "secret_access_key", *secret); err != nil {
 			return diag.FromErr(err)
 		}
 	}
 
+	return resourceGarageKeyRead(ctx, d, m)
+}
+
+// resourceGarageKeyImport creates the key with the configured access key ID and secret
+// using ImportKey, e.g. to keep the credentials of an application migrated to Garage.
+func resourceGarageKeyImport(ctx context.Context, d *schema.ResourceData, m interface{}, name string) diag.Diagnostics {
+	client := m.(*GarageClient)
+	accessKeyID := d.Get("access_key_id").(string)
+	secretAccessKey := d.Get("secret_access_key").(string)
+	if secretAccessKey == "" {
+		wo, diags := d.GetRawConfigAt(cty.GetAttrPath("secret_access_key_wo"))
+		if diags.HasError() {
+			return diags
+		}
+		if wo.IsKnown() && !wo.IsNull() {
+			secretAccessKey = wo.AsString()
+		}
+	}
+
+	// Garage reports duplicates with a generic error, check for them first
+	existing, resp, err := client.Client.AccessKeyAPI.GetKeyInfo(client.WithAuth(ctx)).Id(accessKeyID).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err == nil {
+		return diag.FromErr(fmt.Errorf("failed to import key: access key %s already exists in Garage with name %q", accessKeyID, existing.GetName()))
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return diag.FromErr(fmt.Errorf("failed to check for existing key %s: %w", accessKeyID, err))
+	}
+
+	importReq := garage.NewImportKeyRequest(accessKeyID, secretAccessKey)
+	importReq.SetName(name)
+
+	key, resp, err := client.Client.AccessKeyAPI.ImportKey(client.WithAuth(ctx)).ImportKeyRequest(*importReq).Execute()
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to import key %s: %w", accessKeyID, err))
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	d.SetId(key.GetAccessKeyId())
+
+	// ImportKey only sets the name, apply the other options separately
+	updateReq, err := expandKeyOptions(d, time.Now())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	_, resp, err = client.Client.AccessKeyAPI.UpdateKey(client.WithAuth(ctx)).Id(d.Id()).UpdateKeyRequestBody(*updateReq).Execute()
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to update imported key: %w", err))
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	return resourceGarageKeyRead(ctx, d, m)
+}
+
+// resourceGarageKeyImportState imports a key by access key ID. With an ID of the form
+// <access_key_id>,fetch_secret, fetch_secret is set so that the read following the import
+// fetches the secret from Garage.
+func resourceGarageKeyImportState(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	keyID, fetchSecret, err := parseKeyImportID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	d.SetId(keyID)
+	if err := d.Set("fetch_secret", fetchSecret); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+// parseKeyImportID splits an import ID into the access key ID and the fetch_secret option.
+func parseKeyImportID(id string) (string, bool, error) {
+	keyID, option, found := strings.Cut(id, ",")
+	if !found {
+		return id, false, nil
+	}
+	if option != "fetch_secret" || keyID == "" {
+		return "", false, fmt.Errorf("unexpected import ID %q, expected <access_key_id> or <access_key_id>,fetch_secret", id)
+	}
+	return keyID, true, nil
+}
+
+// validateKeyImportSecret checks that an imported access_key_id comes with its secret,
+// either in secret_access_key or in the write-only secret_access_key_wo.
+func validateKeyImportSecret(ctx context.Context, req schema.ValidateResourceConfigFuncRequest, resp *schema.ValidateResourceConfigFuncResponse) {
+	if req.RawConfig.IsNull() || !req.RawConfig.IsKnown() {
+		return
+	}
+	accessKeyID := req.RawConfig.GetAttr("access_key_id")
+	if accessKeyID.IsNull() {
+		return
+	}
+	if !req.RawConfig.GetAttr("secret_access_key").IsNull() || !req.RawConfig.GetAttr("secret_access_key_wo").IsNull() {
+		return
+	}
+	resp.Diagnostics = append(resp.Diagnostics, diag.Diagnostic{
+		Severity:      diag.Error,
+		Summary:       "Missing secret access key",
+		Detail:        "access_key_id imports existing credentials and requires secret_access_key or secret_access_key_wo.",
+		AttributePath: cty.GetAttrPath("access_key_id"),
+	})
+}
+
+// resourceName returns the configured name, or a unique name beginning with name_prefix
+// (terraform- by default).
+func resourceName(d *schema.ResourceData) string {
+	if name := d.Get("name").(string); name != "" {
+		return name
+	}
+	if prefix := d.Get("name_prefix").(string); prefix != "" {
+		return id.PrefixedUniqueId(prefix)
+	}
+	return id.UniqueId()
+}
+
+// checkKeyNameAvailable refuses names already used by another key when the provider
+// sets unique_key_names.
+func checkKeyNameAvailable(ctx context.Context, client *GarageClient, name string) error {
+	if !client.UniqueKeyNames {
+		return nil
+	}
+	ids, err := keyIDsByName(ctx, client, name)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		return fmt.Errorf("an access key named %q already exists (%s) and the provider sets unique_key_names", name, strings.Join(ids, ", "))
+	}
+	return nil
+}
+
+// keyIDsByName lists the IDs of the access keys with this exact name.
+func keyIDsByName(ctx context.Context, client *GarageClient, name string) ([]string, error) {
+	keys, resp, err := client.Client.AccessKeyAPI.ListKeys(client.WithAuth(ctx)).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	var ids []string
+	for _, key := range keys {
+		if key.GetName() == name {
+			ids = append(ids, key.GetId())
+		}
+	}
+	return ids, nil
+}
+
+// expandKeyOptions builds the permissions and expiration of a new key.
+func expandKeyOptions(d *schema.ResourceData, now time.Time) (*garage.UpdateKeyRequestBody, error) {
+	req := garage.NewUpdateKeyRequestBody()
+
+	if d.Get("allow_create_bucket").(bool) {
+		perm := garage.NewKeyPerm()
+		perm.SetCreateBucket(true)
+		req.SetAllow(*perm)
+	}
+
+	expiration, err := keyExpiration(d.Get("expiration").(string), d.Get("expires_in").(string), now)
+	if err != nil {
+		return nil, err
+	}
+	if expiration != nil {
+		req.SetExpiration(*expiration)
+	}
+	if d.Get("never_expires").(bool) {
+		req.SetNeverExpires(true)
+	}
+
+	return req, nil
+}
+
+// keyExpiration returns the expiration time set by either expiration or expires_in, or nil.
+func keyExpiration(expiration, expiresIn string, now time.Time) (*time.Time, error) {
+	if expiration != "" {
+		t, err := time.Parse(time.RFC3339, expiration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiration format: %w", err)
+		}
+		return &t, nil
+	}
+	if expiresIn != "" {
+		duration, err := time.ParseDuration(expiresIn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expires_in: %w", err)
+		}
+		t := now.Add(duration).UTC().Truncate(time.Second)
+		return &t, nil
+	}
+	return nil, nil
+}
+
+// keyReadyForRotation reports whether a key expiring at expiration must be rotated at now.
+// Keys without expiration or without rotation window are never rotated.
+func keyReadyForRotation(expiration string, window time.Duration, now time.Time) bool {
+	if expiration == "" || window <= 0 {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, expiration)
+	if err != nil {
+		return false
+	}
+	return !now.Add(window).Before(t)
+}
+
+func validateDuration(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value == "" {
+		return
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a duration such as 720h: %w", k, err))
+		return
+	}
+	if duration <= 0 {
+		errors = append(errors, fmt.Errorf("%q must be positive", k))
+	}
+	return
+}
+
+// suppressEquivalentTime ignores the difference between two RFC3339 times of the same second, as
+// Read stores the expiration returned by Garage in UTC without fractional seconds.
+func suppressEquivalentTime(k, old, new string, d *schema.ResourceData) bool {
+	o, err := time.Parse(time.RFC3339, old)
+	if err != nil {
+		return false
+	}
+	n, err := time.Parse(time.RFC3339, new)
+	if err != nil {
+		return false
+	}
+	return o.Truncate(time.Second).Equal(n.Truncate(time.Second))
+}
+
+// resourceGarageKeyCustomizeDiff replaces keys that Read marked as ready for rotation, the same way
+// the tls provider renews certificates, and plans a new expiration when expires_in changes.
+func resourceGarageKeyCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	// A new pgp_key needs a new secret to encrypt. Without a secret in the state, e.g. after an
+	// import, the secret fetched with fetch_secret is encrypted in place instead
+	if d.HasChange("pgp_key") && (d.Get("secret_access_key").(string) != "" || d.Get("encrypted_secret").(string) != "") {
+		if err := d.ForceNew("pgp_key"); err != nil {
+			return err
+		}
+	}
+
+	// Garage only drops the never-expires flag when it is given an expiration
+	if old, new := d.GetChange("never_expires"); old.(bool) && !new.(bool) {
+		if !d.HasChange("expiration") && d.Get("expires_in").(string) == "" {
+			return fmt.Errorf("turning off never_expires requires expiration or expires_in")
+		}
+	}
+
+	if d.HasChange("expires_in") && d.Get("expires_in").(string) != "" {
+		if err := d.SetNewComputed("expiration"); err != nil {
+			return err
+		}
+	}
+
+	if d.Get("ready_for_rotation").(bool) {
+		if err := d.SetNew("ready_for_rotation", false); err != nil {
+			return err
+		}
+		return d.ForceNew("ready_for_rotation")
+	}
+
 	return nil
 }
 
@@ -71,7 +482,10 @@ func resourceGarageKeyRead(ctx context.Context, d *schema.ResourceData, m interf
 	client := m.(*GarageClient)
 	keyID := d.Id()
 
-	key, resp, err := client.Client.AccessKeyAPI.GetKeyInfo(client.WithAuth(ctx)).Id(keyID).Execute()
+	// Only ask for the secret when it is missing, to keep it out of the other responses
+	fetchSecret := d.Get("fetch_secret").(bool) && d.Get("secret_access_key").(string) == "" && d.Get("encrypted_secret").(string) == ""
+
+	key, resp, err := client.Client.AccessKeyAPI.GetKeyInfo(client.WithAuth(ctx)).Id(keyID).ShowSecretKey(fetchSecret).Execute()
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			d.SetId("")
@@ -85,12 +499,53 @@ func resourceGarageKeyRead(ctx context.Context, d *schema.ResourceData, m interf
 		}
 	}()
 
+	if fetchSecret {
+		secret, ok := key.GetSecretAccessKeyOk()
+		if !ok || secret == nil {
+			return diag.FromErr(fmt.Errorf("failed to fetch the secret of key %s: Garage did not return it", keyID))
+		}
+		if err := setSecret(d, "secret_access_key", *secret); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	if err := d.Set("access_key_id", key.GetAccessKeyId()); err != nil {
 		return diag.FromErr(err)
 	}
 	if err := d.Set("name", key.GetName()); err != nil {
 		return diag.FromErr(err)
 	}
+	permissions := key.GetPermissions()
+	if err := d.Set("allow_create_bucket", permissions.GetCreateBucket()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	expiration := ""
+	if t, ok := key.GetExpirationOk(); ok && t != nil {
+		expiration = t.Format(time.RFC3339)
+	}
+	if err := d.Set("expiration", expiration); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("expired", key.GetExpired()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("created", key.GetCreated().Format(time.RFC3339)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("buckets", flattenKeyBuckets(key.GetBuckets())); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var window time.Duration
+	if v := d.Get("rotate_before_expiry").(string); v != "" {
+		if window, err = time.ParseDuration(v); err != nil {
+			return diag.FromErr(fmt.Errorf("invalid rotate_before_expiry: %w", err))
+		}
+	}
+	if err := d.Set("ready_for_rotation", keyReadyForRotation(expiration, window, time.Now())); err != nil {
+		return diag.FromErr(err)
+	}
 
 	return nil
 }
@@ -99,11 +554,40 @@ func resourceGarageKeyUpdate(ctx context.Context, d *schema.ResourceData, m inte
 	client := m.(*GarageClient)
 	keyID := d.Id()
 
-	if d.HasChange("name") {
+	if d.HasChanges("name", "allow_create_bucket", "expiration", "expires_in", "never_expires") {
 		name := d.Get("name").(string)
+		if d.HasChange("name") {
+			if err := checkKeyNameAvailable(ctx, client, name); err != nil {
+				return diag.FromErr(err)
+			}
+		}
 		updateReq := garage.NewUpdateKeyRequestBody()
 		updateReq.SetName(name)
 
+		if d.HasChange("allow_create_bucket") {
+			perm := garage.NewKeyPerm()
+			perm.SetCreateBucket(true)
+			if d.Get("allow_create_bucket").(bool) {
+				updateReq.SetAllow(*perm)
+			} else {
+				updateReq.SetDeny(*perm)
+			}
+		}
+
+		if d.HasChange("expiration") || d.HasChange("expires_in") {
+			expiration, err := keyExpiration(d.Get("expiration").(string), d.Get("expires_in").(string), time.Now())
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			if expiration != nil {
+				updateReq.SetExpiration(*expiration)
+			}
+		}
+
+		if d.HasChange("never_expires") && d.Get("never_expires").(bool) {
+			updateReq.SetNeverExpires(true)
+		}
+
 		_, resp, err := client.Client.AccessKeyAPI.UpdateKey(client.WithAuth(ctx)).Id(keyID).UpdateKeyRequestBody(*updateReq).Execute()
 		if err != nil {
 			return diag.FromErr(fmt.Errorf("failed to update key: %w", err))
@@ -135,3 +619,18 @@ func resourceGarageKeyDelete(ctx context.Context, d *schema.ResourceData, m inte
 	d.SetId("")
 	return nil
 }
+
+// deleteKeyIfExists deletes a key. Deleting a missing key is not an error.
+func deleteKeyIfExists(ctx context.Context, client *GarageClient, keyID string) error {
+	resp, err := client.Client.AccessKeyAPI.DeleteKey(client.WithAuth(ctx)).Id(keyID).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete key %s: %w", keyID, err)
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceGarageKeyPermissions() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceGarageKeyPermissionsCreate,
+		ReadContext:   resourceGarageKeyPermissionsRead,
+		UpdateContext: resourceGarageKeyPermissionsUpdate,
+		DeleteContext: resourceGarageKeyPermissionsDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceGarageKeyPermissionsImport,
+		},
+		ValidateRawResourceConfigFuncs: []schema.ValidateRawResourceConfigFunc{
+			validateKeyPermissionBlocks,
+		},
+		Schema: map[string]*schema.Schema{
+			"access_key_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The access key ID",
+			},
+			"authoritative": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Revoke the permissions of the key on buckets that are not listed, including grants of garage_bucket_key and of garage_bucket local_alias. When false, only the listed buckets are managed",
+			},
+			"bucket": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Permissions of the key on a bucket",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"bucket_id": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The bucket ID",
+						},
+						"read": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Grant read permission",
+						},
+						"write": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Grant write permission",
+						},
+						"owner": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Grant owner permission",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// validateKeyPermissionBlocks rejects at plan time the bucket blocks that expandKeyPermissions
+// would reject at apply time. Unknown values are skipped.
+func validateKeyPermissionBlocks(ctx context.Context, req schema.ValidateResourceConfigFuncRequest, resp *schema.ValidateResourceConfigFuncResponse) {
+	if req.RawConfig.IsNull() || !req.RawConfig.IsKnown() {
+		return
+	}
+	blocks := req.RawConfig.GetAttr("bucket")
+	if blocks.IsNull() || !blocks.IsKnown() {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for it := blocks.ElementIterator(); it.Next(); {
+		_, block := it.Element()
+		if block.IsNull() || !block.IsKnown() {
+			continue
+		}
+
+		if id := block.GetAttr("bucket_id"); id.IsKnown() && !id.IsNull() {
+			if seen[id.AsString()] {
+				resp.Diagnostics = append(resp.Diagnostics, diag.Diagnostic{
+					Severity:      diag.Error,
+					Summary:       "Duplicate bucket",
+					Detail:        fmt.Sprintf("Bucket %s is listed more than once.", id.AsString()),
+					AttributePath: cty.GetAttrPath("bucket"),
+				})
+			}
+			seen[id.AsString()] = true
+		}
+
+		grants := false
+		for _, flag := range bucketPermFlags {
+			v := block.GetAttr(flag)
+			if !v.IsKnown() || (!v.IsNull() && v.True()) {
+				grants = true
+			}
+		}
+		if !grants {
+			resp.Diagnostics = append(resp.Diagnostics, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Bucket block without permission",
+				Detail:        "Each bucket block must grant read, write or owner. Remove the block to revoke access to the bucket.",
+				AttributePath: cty.GetAttrPath("bucket"),
+			})
+		}
+	}
+}
+
+// expandKeyPermissions indexes bucket blocks by bucket ID.
+func expandKeyPermissions(blocks []interface{}) (map[string]bucketPerms, error) {
+	result := make(map[string]bucketPerms, len(blocks))
+	for _, raw := range blocks {
+		block := raw.(map[string]interface{})
+		id := block["bucket_id"].(string)
+		if _, ok := result[id]; ok {
+			return nil, fmt.Errorf("bucket %s is listed more than once", id)
+		}
+		p := bucketPerms{
+			Read:  block["read"].(bool),
+			Write: block["write"].(bool),
+			Owner: block["owner"].(bool),
+		}
+		if !p.any() {
+			return nil, fmt.Errorf("bucket %s grants no permission, remove the block to revoke access", id)
+		}
+		result[id] = p
+	}
+	return result, nil
+}
+
+func flattenKeyPermissions(perms map[string]bucketPerms) []interface{} {
+	result := make([]interface{}, 0, len(perms))
+	for id, p := range perms {
+		result = append(result, map[string]interface{}{
+			"bucket_id": id,
+			"read":      p.Read,
+			"write":     p.Write,
+			"owner":     p.Owner,
+		})
+	}
+	return result
+}
+
+// applyKeyPermissions makes the bucket permissions of the key match the configuration.
+func applyKeyPermissions(ctx context.Context, d *schema.ResourceData, client *GarageClient) error {
+	keyID := d.Get("access_key_id").(string)
+
+	desired, err := expandKeyPermissions(d.Get("bucket").(*schema.Set).List())
+	if err != nil {
+		return err
+	}
+	oldBuckets, _ := d.GetChange("bucket")
+	var previous []string
+	for _, raw := range oldBuckets.(*schema.Set).List() {
+		previous = append(previous, raw.(map[string]interface{})["bucket_id"].(string))
+	}
+
+	current, err := getKeyBucketPerms(ctx, client, keyID)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range planKeyPermissions(current, desired, previous, d.Get("authoritative").(bool)) {
+		if err := setBucketPerms(ctx, client, keyID, change.BucketID, change.Current, change.Desired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resourceGarageKeyPermissionsCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+
+	if err := applyKeyPermissions(ctx, d, client); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(d.Get("access_key_id").(string))
+
+	return resourceGarageKeyPermissionsRead(ctx, d, m)
+}
+
+func resourceGarageKeyPermissionsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	keyID := d.Id()
+
+	key, resp, err := client.Client.AccessKeyAPI.GetKeyInfo(client.WithAuth(ctx)).Id(keyID).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(fmt.Errorf("failed to read key: %w", err))
+	}
+
+	perms := keyBucketPerms(key.GetBuckets())
+	if !d.Get("authoritative").(bool) {
+		// Only report the buckets this resource manages
+		managed := make(map[string]bucketPerms)
+		for _, raw := range d.Get("bucket").(*schema.Set).List() {
+			id := raw.(map[string]interface{})["bucket_id"].(string)
+			if p, ok := perms[id]; ok {
+				managed[id] = p
+			}
+		}
+		perms = managed
+	}
+
+	if err := d.Set("access_key_id", keyID); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("bucket", flattenKeyPermissions(perms)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceGarageKeyPermissionsUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+
+	if err := applyKeyPermissions(ctx, d, client); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceGarageKeyPermissionsRead(ctx, d, m)
+}
+
+func resourceGarageKeyPermissionsDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	keyID := d.Id()
+
+	key, resp, err := client.Client.AccessKeyAPI.GetKeyInfo(client.WithAuth(ctx)).Id(keyID).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(fmt.Errorf("failed to read key: %w", err))
+	}
+	current := keyBucketPerms(key.GetBuckets())
+
+	// Revoke the permissions in the state: every permission of the key in authoritative mode,
+	// only the listed buckets otherwise
+	for _, raw := range d.Get("bucket").(*schema.Set).List() {
+		id := raw.(map[string]interface{})["bucket_id"].(string)
+		if err := setBucketPerms(ctx, client, keyID, id, current[id], bucketPerms{}); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func resourceGarageKeyPermissionsImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if err := d.Set("access_key_id", d.Id()); err != nil {
+		return nil, err
+	}
+	if err := d.Set("authoritative", false); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
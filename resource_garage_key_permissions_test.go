@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestExpandKeyPermissions(t *testing.T) {
+	block := func(id string, read, write, owner bool) interface{} {
+		return map[string]interface{}{"bucket_id": id, "read": read, "write": write, "owner": owner}
+	}
+
+	tests := []struct {
+		name      string
+		blocks    []interface{}
+		expected  map[string]bucketPerms
+		expectErr bool
+	}{
+		{"empty", nil, map[string]bucketPerms{}, false},
+		{
+			"two buckets",
+			[]interface{}{block("a", true, false, false), block("b", true, true, true)},
+			map[string]bucketPerms{"a": {Read: true}, "b": {Read: true, Write: true, Owner: true}},
+			false,
+		},
+		{"duplicate bucket", []interface{}{block("a", true, false, false), block("a", true, true, false)}, nil, true},
+		{"no permission", []interface{}{block("a", false, false, false)}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := expandKeyPermissions(tt.blocks)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expandKeyPermissions() = %+v, expected %+v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateKeyPermissionBlocks(t *testing.T) {
+	block := func(id, read, write, owner cty.Value) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{"bucket_id": id, "read": read, "write": write, "owner": owner})
+	}
+	config := func(blocks ...cty.Value) cty.Value {
+		buckets := cty.NullVal(cty.Set(block(cty.StringVal(""), cty.False, cty.False, cty.False).Type()))
+		if len(blocks) > 0 {
+			buckets = cty.SetVal(blocks)
+		}
+		return cty.ObjectVal(map[string]cty.Value{"bucket": buckets})
+	}
+	null := cty.NullVal(cty.Bool)
+	unknown := cty.UnknownVal(cty.Bool)
+
+	tests := []struct {
+		name     string
+		config   cty.Value
+		hasError bool
+	}{
+		{"no bucket", config(), false},
+		{"read", config(block(cty.StringVal("a"), cty.True, null, null)), false},
+		{"no permission", config(block(cty.StringVal("a"), null, cty.False, null)), true},
+		{"unknown permission", config(block(cty.StringVal("a"), unknown, null, null)), false},
+		{"duplicate bucket", config(block(cty.StringVal("a"), cty.True, null, null), block(cty.StringVal("a"), cty.True, cty.True, null)), true},
+		{"unknown bucket IDs", config(block(cty.UnknownVal(cty.String), cty.True, null, null), block(cty.UnknownVal(cty.String), cty.True, cty.True, null)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &schema.ValidateResourceConfigFuncResponse{}
+			validateKeyPermissionBlocks(context.Background(), schema.ValidateResourceConfigFuncRequest{RawConfig: tt.config}, resp)
+			if tt.hasError != resp.Diagnostics.HasError() {
+				t.Errorf("validateKeyPermissionBlocks() diagnostics = %v, expected error: %v", resp.Diagnostics, tt.hasError)
+			}
+		})
+	}
+}
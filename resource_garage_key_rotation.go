@@ -0,0 +1,504 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	garage "git.deuxfleurs.fr/garage-sdk/garage-admin-sdk-golang"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// rotatedKeySchema describes the current and previous keys of a garage_key_rotation.
+func rotatedKeySchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: description,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"access_key_id": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The access key ID",
+				},
+				"secret_access_key": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Sensitive:   true,
+					Description: "The secret access key",
+				},
+				"name": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The name of the access key",
+				},
+				"created": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "Creation date",
+				},
+			},
+		},
+	}
+}
+
+func resourceGarageKeyRotation() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceGarageKeyRotationCreate,
+		ReadContext:   resourceGarageKeyRotationRead,
+		UpdateContext: resourceGarageKeyRotationUpdate,
+		DeleteContext: resourceGarageKeyRotationDelete,
+		CustomizeDiff: resourceGarageKeyRotationCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Base name of the keys. Each key is named after it with its creation time as suffix",
+			},
+			"rotation_interval": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+				Description:  "Rotate the keys when the current key is older than this duration (e.g., 720h)",
+			},
+			"keepers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary values that trigger a rotation when they change",
+			},
+			"current":     rotatedKeySchema("The key to use"),
+			"previous":    rotatedKeySchema("The key replaced by the last rotation. It keeps working until the next rotation"),
+			"rotated_out": rotatedKeySchema("Keys rotated out whose deletion failed. Every apply and the destroy try to delete them again"),
+			"rotated_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Time of the last rotation (RFC3339 format)",
+			},
+			"ready_for_rotation": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether rotation_interval has elapsed and the next apply rotates the keys",
+			},
+		},
+	}
+}
+
+// resourceGarageKeyRotationCustomizeDiff plans a rotation when keepers change or when Read found
+// that rotation_interval has elapsed.
+func resourceGarageKeyRotationCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	// Keys whose deletion failed are deleted again by the next apply
+	if len(d.Get("rotated_out").([]interface{})) > 0 {
+		if err := d.SetNewComputed("rotated_out"); err != nil {
+			return err
+		}
+	}
+
+	if !d.HasChange("keepers") && !d.Get("ready_for_rotation").(bool) {
+		return nil
+	}
+
+	if err := d.SetNew("ready_for_rotation", false); err != nil {
+		return err
+	}
+	for _, k := range []string{"current", "previous", "rotated_at", "rotated_out"} {
+		if err := d.SetNewComputed(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resourceGarageKeyRotationCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	now := time.Now()
+
+	key, err := createRotatedKey(ctx, client, d.Get("name").(string), now)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(key["access_key_id"].(string))
+	if err := d.Set("current", []interface{}{key}); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("previous", []interface{}{}); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("rotated_out", []interface{}{}); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("rotated_at", now.UTC().Format(time.RFC3339)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceGarageKeyRotationRead(ctx, d, m)
+}
+
+func resourceGarageKeyRotationRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+
+	current, err := refreshRotatedKey(ctx, client, d.Get("current").([]interface{}))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	previous, err := refreshRotatedKey(ctx, client, d.Get("previous").([]interface{}))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	rotatedOut := []interface{}{}
+	for _, v := range d.Get("rotated_out").([]interface{}) {
+		key, err := refreshRotatedKey(ctx, client, []interface{}{v})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if key != nil {
+			rotatedOut = append(rotatedOut, key)
+		}
+	}
+	if err := d.Set("rotated_out", rotatedOut); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if current == nil && previous == nil && len(rotatedOut) == 0 {
+		// Both keys were deleted outside of Terraform, create a new pair
+		d.SetId("")
+		return nil
+	}
+	if current == nil {
+		// The current key was deleted outside of Terraform. Keep tracking the other keys and
+		// rotate on the next apply to create a new current key.
+		if err := d.Set("current", []interface{}{}); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := d.Set("previous", optionalKey(previous)); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := d.Set("ready_for_rotation", true); err != nil {
+			return diag.FromErr(err)
+		}
+		return nil
+	}
+
+	if err := d.Set("current", []interface{}{current}); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("previous", optionalKey(previous)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var interval time.Duration
+	if v := d.Get("rotation_interval").(string); v != "" {
+		if interval, err = time.ParseDuration(v); err != nil {
+			return diag.FromErr(fmt.Errorf("invalid rotation_interval: %w", err))
+		}
+	}
+	ready := rotationDue(d.Get("rotated_at").(string), interval, time.Now())
+	if err := d.Set("ready_for_rotation", ready); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceGarageKeyRotationUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	deleteKey := func(keyID string) error {
+		return deleteKeyIfExists(ctx, client, keyID)
+	}
+
+	// Delete again the keys whose deletion failed during an earlier rotation
+	oldRotatedOut, _ := d.GetChange("rotated_out")
+	pending, diags := deleteRotatedOutKeys(oldRotatedOut.([]interface{}), deleteKey)
+	if err := d.Set("rotated_out", pending); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if !d.HasChange("keepers") && !d.HasChange("ready_for_rotation") {
+		return append(diags, resourceGarageKeyRotationRead(ctx, d, m)...)
+	}
+
+	oldCurrent, _ := d.GetChange("current")
+	oldPrevious, _ := d.GetChange("previous")
+	oldRotatedAt, _ := d.GetChange("rotated_at")
+	source, newPrevious, rotatedOut := planRotation(oldCurrent.([]interface{}), oldPrevious.([]interface{}))
+
+	// Keep the planned unknown values out of the state when the rotation fails, so that the
+	// tracked keys are not forgotten
+	fail := func(err error) diag.Diagnostics {
+		diags = append(diags, diag.FromErr(err)...)
+		for k, v := range map[string]interface{}{"current": oldCurrent, "previous": oldPrevious, "rotated_at": oldRotatedAt} {
+			if err := d.Set(k, v); err != nil {
+				diags = append(diags, diag.FromErr(err)...)
+			}
+		}
+		return diags
+	}
+
+	now := time.Now()
+	key, err := createRotatedKey(ctx, client, d.Get("name").(string), now)
+	if err != nil {
+		return fail(err)
+	}
+	keyID := key["access_key_id"].(string)
+
+	if source != nil {
+		if err := copyKeyPermissions(ctx, client, source["access_key_id"].(string), keyID); err != nil {
+			if deleteErr := deleteKey(keyID); deleteErr != nil {
+				err = fmt.Errorf("%w (deleting the new key %s also failed: %v)", err, keyID, deleteErr)
+			}
+			return fail(err)
+		}
+	}
+
+	// Track the new key before anything else can fail, so that it is never left outside the state
+	d.SetId(keyID)
+	if err := d.Set("current", []interface{}{key}); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("previous", newPrevious); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("rotated_at", now.UTC().Format(time.RFC3339)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// The previous key has had a full rotation period to be replaced by its users
+	failed, deleteDiags := deleteRotatedOutKeys(rotatedOut, deleteKey)
+	diags = append(diags, deleteDiags...)
+	if err := d.Set("rotated_out", append(pending, failed...)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return append(diags, resourceGarageKeyRotationRead(ctx, d, m)...)
+}
+
+func resourceGarageKeyRotationDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+
+	for _, keyID := range rotationKeyIDs(d) {
+		if err := deleteKeyIfExists(ctx, client, keyID); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// rotationDue reports whether interval has elapsed since rotatedAt. It is false without interval.
+func rotationDue(rotatedAt string, interval time.Duration, now time.Time) bool {
+	if rotatedAt == "" || interval <= 0 {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, rotatedAt)
+	if err != nil {
+		return false
+	}
+	return !now.Before(t.Add(interval))
+}
+
+// planRotation returns the key whose permissions the new key takes, the previous key after the
+// rotation and the keys to delete once the new key is tracked. The current key becomes the
+// previous key and the previous key is rotated out. When the current key was deleted outside of
+// Terraform, the previous key is the source and is kept. Without any key, source is nil.
+func planRotation(current, previous []interface{}) (source map[string]interface{}, newPrevious, rotatedOut []interface{}) {
+	if len(current) > 0 && current[0] != nil {
+		source = current[0].(map[string]interface{})
+		return source, []interface{}{source}, previous
+	}
+	if len(previous) > 0 && previous[0] != nil {
+		return previous[0].(map[string]interface{}), previous, nil
+	}
+	return nil, []interface{}{}, nil
+}
+
+// deleteRotatedOutKeys deletes keys with deleteKey and returns the keys whose deletion failed,
+// with a warning for each, so that they are kept in rotated_out.
+func deleteRotatedOutKeys(keys []interface{}, deleteKey func(string) error) ([]interface{}, diag.Diagnostics) {
+	failed := []interface{}{}
+	var diags diag.Diagnostics
+	for _, v := range keys {
+		key, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		keyID := key["access_key_id"].(string)
+		if err := deleteKey(keyID); err != nil {
+			failed = append(failed, key)
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("Failed to delete the rotated out key %s", keyID),
+				Detail:   fmt.Sprintf("The key is kept in rotated_out and the next apply or destroy deletes it again: %v", err),
+			})
+		}
+	}
+	return failed, diags
+}
+
+// rotationKeyIDs lists the IDs of every key tracked by a garage_key_rotation, oldest first.
+func rotationKeyIDs(d *schema.ResourceData) []string {
+	var ids []string
+	for _, k := range []string{"rotated_out", "previous", "current"} {
+		for _, v := range d.Get(k).([]interface{}) {
+			if key, ok := v.(map[string]interface{}); ok {
+				ids = append(ids, key["access_key_id"].(string))
+			}
+		}
+	}
+	return ids
+}
+
+// optionalKey returns a block holding key, or an empty block when key is nil.
+func optionalKey(key map[string]interface{}) []interface{} {
+	if key == nil {
+		return []interface{}{}
+	}
+	return []interface{}{key}
+}
+
+// createRotatedKey creates a key named after name and the rotation time.
+func createRotatedKey(ctx context.Context, client *GarageClient, name string, now time.Time) (map[string]interface{}, error) {
+	keyReq := garage.NewUpdateKeyRequestBody()
+	keyReq.SetName(fmt.Sprintf("%s-%s", name, now.UTC().Format("20060102T150405Z")))
+
+	key, resp, err := client.Client.AccessKeyAPI.CreateKey(client.WithAuth(ctx)).Body(*keyReq).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key: %w", err)
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	return map[string]interface{}{
+		"access_key_id":     key.GetAccessKeyId(),
+		"secret_access_key": key.GetSecretAccessKey(),
+		"name":              key.GetName(),
+		"created":           key.GetCreated().Format(time.RFC3339),
+	}, nil
+}
+
+// refreshRotatedKey reads the key stored in a current or previous block and keeps its secret.
+// It returns nil if the block is empty or the key no longer exists.
+func refreshRotatedKey(ctx context.Context, client *GarageClient, block []interface{}) (map[string]interface{}, error) {
+	if len(block) == 0 || block[0] == nil {
+		return nil, nil
+	}
+	stored := block[0].(map[string]interface{})
+	keyID := stored["access_key_id"].(string)
+
+	key, resp, err := client.Client.AccessKeyAPI.GetKeyInfo(client.WithAuth(ctx)).Id(keyID).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read key %s: %w", keyID, err)
+	}
+
+	return map[string]interface{}{
+		"access_key_id":     key.GetAccessKeyId(),
+		"secret_access_key": stored["secret_access_key"],
+		"name":              key.GetName(),
+		"created":           key.GetCreated().Format(time.RFC3339),
+	}, nil
+}
+
+// copyKeyPermissions grants toID the bucket permissions, local aliases and key-level permissions of fromID.
+func copyKeyPermissions(ctx context.Context, client *GarageClient, fromID, toID string) error {
+	from, resp, err := client.Client.AccessKeyAPI.GetKeyInfo(client.WithAuth(ctx)).Id(fromID).Execute()
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read key %s: %w", fromID, err)
+	}
+
+	for _, bucket := range from.GetBuckets() {
+		perms := bucket.GetPermissions()
+		if !perms.GetRead() && !perms.GetWrite() && !perms.GetOwner() {
+			continue
+		}
+
+		if err := allowBucketKey(ctx, client, toID, bucket.GetId(), perms.GetRead(), perms.GetWrite(), perms.GetOwner()); err != nil {
+			return fmt.Errorf("failed to copy permissions to key %s: %w", toID, err)
+		}
+		for _, alias := range bucket.GetLocalAliases() {
+			if err := addLocalBucketAlias(ctx, client, bucket.GetId(), toID, alias); err != nil {
+				return fmt.Errorf("failed to copy local aliases to key %s: %w", toID, err)
+			}
+		}
+	}
+
+	keyPerms := from.GetPermissions()
+	if keyPerms.GetCreateBucket() {
+		perm := garage.NewKeyPerm()
+		perm.SetCreateBucket(true)
+		updateReq := garage.NewUpdateKeyRequestBody()
+		updateReq.SetAllow(*perm)
+
+		_, resp, err := client.Client.AccessKeyAPI.UpdateKey(client.WithAuth(ctx)).Id(toID).UpdateKeyRequestBody(*updateReq).Execute()
+		if resp != nil && resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy key permissions to key %s: %w", toID, err)
+		}
+	}
+
+	return nil
+}
+
+// addBucketAliasRequest is the body of AddBucketAlias for a local alias.
+type addBucketAliasRequest struct {
+	BucketID    string `json:"bucketId"`
+	AccessKeyID string `json:"accessKeyId"`
+	LocalAlias  string `json:"localAlias"`
+}
+
+// addLocalBucketAlias gives a bucket a local alias in the namespace of a key. The request is sent
+// as raw JSON, like updateClusterLayoutRaw, as the alias is a oneOf in the API schema.
+func addLocalBucketAlias(ctx context.Context, client *GarageClient, bucketID, keyID, alias string) error {
+	jsonData, err := json.Marshal(addBucketAliasRequest{BucketID: bucketID, AccessKeyID: keyID, LocalAlias: alias})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s://%s/v2/AddBucketAlias", client.Scheme, client.Host)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+client.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to add local alias %q on bucket %s (status %d): %s", alias, bucketID, resp.StatusCode, string(body))
+	}
+	return nil
+}
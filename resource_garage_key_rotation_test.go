@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRotationDue(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		rotatedAt string
+		interval  time.Duration
+		expected  bool
+	}{
+		{"no interval", "2026-01-01T00:00:00Z", 0, false},
+		{"never rotated", "", time.Hour, false},
+		{"interval not elapsed", "2026-10-19T00:00:00Z", 24 * time.Hour, false},
+		{"interval elapsed", "2026-10-18T00:00:00Z", 24 * time.Hour, true},
+		{"interval boundary", "2026-10-18T12:00:00Z", 24 * time.Hour, true},
+		{"invalid rotated_at", "yesterday", time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := rotationDue(tt.rotatedAt, tt.interval, now); result != tt.expected {
+				t.Errorf("rotationDue(%q, %s) = %v, expected %v", tt.rotatedAt, tt.interval, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPlanRotation(t *testing.T) {
+	current := map[string]interface{}{"access_key_id": "GKcurrent"}
+	previous := map[string]interface{}{"access_key_id": "GKprevious"}
+
+	tests := []struct {
+		name               string
+		current            []interface{}
+		previous           []interface{}
+		expectedSource     string
+		expectedPrevious   []string
+		expectedRotatedOut []string
+	}{
+		{"first rotation", []interface{}{current}, []interface{}{}, "GKcurrent", []string{"GKcurrent"}, nil},
+		{"rotation", []interface{}{current}, []interface{}{previous}, "GKcurrent", []string{"GKcurrent"}, []string{"GKprevious"}},
+		{"current key deleted", []interface{}{}, []interface{}{previous}, "GKprevious", []string{"GKprevious"}, nil},
+		{"no key", []interface{}{}, []interface{}{}, "", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, newPrevious, rotatedOut := planRotation(tt.current, tt.previous)
+			sourceID := ""
+			if source != nil {
+				sourceID = source["access_key_id"].(string)
+			}
+			if sourceID != tt.expectedSource {
+				t.Errorf("source = %q, expected %q", sourceID, tt.expectedSource)
+			}
+			if got := keyIDs(newPrevious); !reflect.DeepEqual(got, tt.expectedPrevious) {
+				t.Errorf("previous = %v, expected %v", got, tt.expectedPrevious)
+			}
+			if got := keyIDs(rotatedOut); !reflect.DeepEqual(got, tt.expectedRotatedOut) {
+				t.Errorf("rotated out = %v, expected %v", got, tt.expectedRotatedOut)
+			}
+		})
+	}
+}
+
+func TestDeleteRotatedOutKeys(t *testing.T) {
+	keys := []interface{}{
+		map[string]interface{}{"access_key_id": "GKdeleted"},
+		map[string]interface{}{"access_key_id": "GKfailing"},
+	}
+	deleteKey := func(keyID string) error {
+		if keyID == "GKfailing" {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	failed, diags := deleteRotatedOutKeys(keys, deleteKey)
+	if got := keyIDs(failed); !reflect.DeepEqual(got, []string{"GKfailing"}) {
+		t.Errorf("failed = %v, expected [GKfailing]", got)
+	}
+	if len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Errorf("diagnostics = %v, expected one warning", diags)
+	}
+
+	failed, diags = deleteRotatedOutKeys(failed, func(string) error { return nil })
+	if len(failed) != 0 || len(diags) != 0 {
+		t.Errorf("retry: failed = %v, diagnostics = %v, expected none", failed, diags)
+	}
+}
+
+func TestRotationKeyIDs(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceGarageKeyRotation().Schema, map[string]interface{}{"name": "app"})
+	d.SetId("GKold")
+
+	// A rotation from GKold to GKnew whose deletion of GKoldest failed
+	source, newPrevious, rotatedOut := planRotation(
+		[]interface{}{map[string]interface{}{"access_key_id": "GKold"}},
+		[]interface{}{map[string]interface{}{"access_key_id": "GKoldest"}},
+	)
+	if source["access_key_id"] != "GKold" {
+		t.Fatalf("source = %v, expected GKold", source)
+	}
+	failed, _ := deleteRotatedOutKeys(rotatedOut, func(string) error { return errors.New("timeout") })
+	d.SetId("GKnew")
+	for k, v := range map[string][]interface{}{
+		"current":     {map[string]interface{}{"access_key_id": "GKnew"}},
+		"previous":    newPrevious,
+		"rotated_out": failed,
+	} {
+		if err := d.Set(k, v); err != nil {
+			t.Fatalf("failed to set %s: %v", k, err)
+		}
+	}
+
+	if d.Id() != "GKnew" {
+		t.Errorf("ID = %q, expected GKnew", d.Id())
+	}
+	expected := []string{"GKoldest", "GKold", "GKnew"}
+	if got := rotationKeyIDs(d); !reflect.DeepEqual(got, expected) {
+		t.Errorf("rotationKeyIDs() = %v, expected %v", got, expected)
+	}
+}
+
+func keyIDs(keys []interface{}) []string {
+	var ids []string
+	for _, v := range keys {
+		ids = append(ids, v.(map[string]interface{})["access_key_id"].(string))
+	}
+	return ids
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestKeyExpiration(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name       string
+		expiration string
+		expiresIn  string
+		expected   string
+		hasError   bool
+	}{
+		{"none", "", "", "", false},
+		{"absolute", "2027-01-01T00:00:00Z", "", "2027-01-01T00:00:00Z", false},
+		{"relative", "", "720h", "2026-11-18T12:00:00Z", false},
+		{"relative minutes", "", "90m", "2026-10-19T13:30:00Z", false},
+		{"invalid absolute", "2027-01-01", "", "", true},
+		{"invalid relative", "", "30d", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := keyExpiration(tt.expiration, tt.expiresIn, now)
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("expected error, got %v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := ""
+			if result != nil {
+				got = result.Format(time.RFC3339)
+			}
+			if got != tt.expected {
+				t.Errorf("keyExpiration() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKeyReadyForRotation(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		expiration string
+		window     time.Duration
+		expected   bool
+	}{
+		{"no expiration", "", 24 * time.Hour, false},
+		{"no window", "2026-10-19T13:00:00Z", 0, false},
+		{"outside window", "2026-10-21T12:00:00Z", 24 * time.Hour, false},
+		{"inside window", "2026-10-20T11:00:00Z", 24 * time.Hour, true},
+		{"window boundary", "2026-10-20T12:00:00Z", 24 * time.Hour, true},
+		{"already expired", "2026-10-18T12:00:00Z", time.Hour, true},
+		{"invalid expiration", "tomorrow", time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := keyReadyForRotation(tt.expiration, tt.window, now); result != tt.expected {
+				t.Errorf("keyReadyForRotation(%q, %s) = %v, expected %v", tt.expiration, tt.window, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		hasError bool
+	}{
+		{"", false},
+		{"720h", false},
+		{"1h30m", false},
+		{"30d", true},
+		{"0s", true},
+		{"-1h", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, errs := validateDuration(tt.input, "expires_in")
+			if tt.hasError != (len(errs) > 0) {
+				t.Errorf("validateDuration(%q) errors = %v, expected error: %v", tt.input, errs, tt.hasError)
+			}
+		})
+	}
+}
+
+func TestValidateKeyImportSecret(t *testing.T) {
+	config := func(accessKeyID, secret, secretWO cty.Value) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"access_key_id":        accessKeyID,
+			"secret_access_key":    secret,
+			"secret_access_key_wo": secretWO,
+		})
+	}
+	null := cty.NullVal(cty.String)
+	keyID := cty.StringVal("GK31c2f218a2e44f485b94239e")
+	secret := cty.StringVal("b892c0665f0ada8a4755dae98baa3b133590e11dae3bcc1f9d769d67f16c3835")
+
+	tests := []struct {
+		name     string
+		config   cty.Value
+		hasError bool
+	}{
+		{"generated key", config(null, null, null), false},
+		{"import with secret", config(keyID, secret, null), false},
+		{"import with write-only secret", config(keyID, null, secret), false},
+		{"import without secret", config(keyID, null, null), true},
+		{"unknown secret", config(keyID, cty.UnknownVal(cty.String), null), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &schema.ValidateResourceConfigFuncResponse{}
+			validateKeyImportSecret(context.Background(), schema.ValidateResourceConfigFuncRequest{RawConfig: tt.config}, resp)
+			if tt.hasError != resp.Diagnostics.HasError() {
+				t.Errorf("validateKeyImportSecret() diagnostics = %v, expected error: %v", resp.Diagnostics, tt.hasError)
+			}
+		})
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		expected string
+		prefix   string
+	}{
+		{"name", map[string]interface{}{"name": "loki"}, "loki", ""},
+		{"name prefix", map[string]interface{}{"name_prefix": "loki-"}, "", "loki-"},
+		{"generated", map[string]interface{}{}, "", "terraform-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceGarageKey().Schema, tt.config)
+			result := resourceName(d)
+			if tt.expected != "" && result != tt.expected {
+				t.Errorf("resourceName() = %q, expected %q", result, tt.expected)
+			}
+			if tt.prefix != "" && (!strings.HasPrefix(result, tt.prefix) || len(result) <= len(tt.prefix)) {
+				t.Errorf("resourceName() = %q, expected a unique name beginning with %q", result, tt.prefix)
+			}
+		})
+	}
+}
+
+func TestResourceGarageKeyRotateBeforeExpiryConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		hasError bool
+	}{
+		{"with expires_in", map[string]interface{}{"name": "app", "expires_in": "720h", "rotate_before_expiry": "168h"}, false},
+		{"without expiry", map[string]interface{}{"name": "app", "rotate_before_expiry": "168h"}, true},
+		{"with fixed expiration", map[string]interface{}{"name": "app", "expiration": "2030-01-01T00:00:00Z", "rotate_before_expiry": "168h"}, true},
+		{"with imported credentials", map[string]interface{}{
+			"name":                 "app",
+			"access_key_id":        "GK31c2f218a2e44f485b94239e",
+			"secret_access_key":    "b892c0665f0ada8a4755dae98baa3b133590e11dae3bcc1f9d769d67f16c3835",
+			"expires_in":           "720h",
+			"rotate_before_expiry": "168h",
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := resourceGarageKey().Validate(terraform.NewResourceConfigRaw(tt.config))
+			if tt.hasError != diags.HasError() {
+				t.Errorf("Validate() diagnostics = %v, expected error: %v", diags, tt.hasError)
+			}
+		})
+	}
+}
+
+func TestSuppressEquivalentTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected bool
+	}{
+		{"same value", "2027-01-01T00:00:00Z", "2027-01-01T00:00:00Z", true},
+		{"other offset", "2026-12-31T22:00:00Z", "2027-01-01T00:00:00+02:00", true},
+		{"fractional seconds", "2027-01-01T00:00:00Z", "2027-01-01T00:00:00.250Z", true},
+		{"other instant", "2027-01-01T00:00:00Z", "2027-01-01T00:00:00+02:00", false},
+		{"no expiration", "", "2027-01-01T00:00:00Z", false},
+		{"removed", "2027-01-01T00:00:00Z", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := suppressEquivalentTime("expiration", tt.old, tt.new, nil); result != tt.expected {
+				t.Errorf("suppressEquivalentTime(%q, %q) = %v, expected %v", tt.old, tt.new, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResourceGarageKeyExpirationDiff(t *testing.T) {
+	state := func(expiration, neverExpires string) *terraform.InstanceState {
+		return &terraform.InstanceState{
+			ID: "GK31c2f218a2e44f485b94239e",
+			Attributes: map[string]string{
+				"id":                  "GK31c2f218a2e44f485b94239e",
+				"name":                "app",
+				"access_key_id":       "GK31c2f218a2e44f485b94239e",
+				"expiration":          expiration,
+				"never_expires":       neverExpires,
+				"allow_create_bucket": "false",
+				"buckets.#":           "0",
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		state      *terraform.InstanceState
+		config     map[string]interface{}
+		expectDiff bool
+		hasError   bool
+	}{
+		{"expiration with another offset", state("2026-12-31T22:00:00Z", "false"),
+			map[string]interface{}{"name": "app", "expiration": "2027-01-01T00:00:00+02:00"}, false, false},
+		{"expiration with fractional seconds", state("2027-01-01T00:00:00Z", "false"),
+			map[string]interface{}{"name": "app", "expiration": "2027-01-01T00:00:00.500Z"}, false, false},
+		{"expiration changed", state("2027-01-01T00:00:00Z", "false"),
+			map[string]interface{}{"name": "app", "expiration": "2028-01-01T00:00:00Z"}, true, false},
+		{"never_expires turned off alone", state("", "true"),
+			map[string]interface{}{"name": "app"}, true, true},
+		{"never_expires turned off with expiration", state("", "true"),
+			map[string]interface{}{"name": "app", "expiration": "2027-01-01T00:00:00Z"}, true, false},
+		{"never_expires turned off with expires_in", state("", "true"),
+			map[string]interface{}{"name": "app", "expires_in": "720h"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := resourceGarageKey().Diff(context.Background(), tt.state, terraform.NewResourceConfigRaw(tt.config), nil)
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("expected an error, got diff %v", diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			hasDiff := diff != nil && !diff.Empty()
+			if hasDiff != tt.expectDiff {
+				t.Errorf("diff = %v, expected a diff: %v", diff, tt.expectDiff)
+			}
+		})
+	}
+}
+
+func TestParseKeyImportID(t *testing.T) {
+	tests := []struct {
+		id          string
+		keyID       string
+		fetchSecret bool
+		hasError    bool
+	}{
+		{"GK31c2f218a2e44f485b94239e", "GK31c2f218a2e44f485b94239e", false, false},
+		{"GK31c2f218a2e44f485b94239e,fetch_secret", "GK31c2f218a2e44f485b94239e", true, false},
+		{"GK31c2f218a2e44f485b94239e,secret", "", false, true},
+		{",fetch_secret", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			keyID, fetchSecret, err := parseKeyImportID(tt.id)
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("expected error, got %q", keyID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if keyID != tt.keyID || fetchSecret != tt.fetchSecret {
+				t.Errorf("parseKeyImportID(%q) = %q, %v, expected %q, %v", tt.id, keyID, fetchSecret, tt.keyID, tt.fetchSecret)
+			}
+		})
+	}
+}
+
+func TestResourceGarageKeyPGPKeyDiff(t *testing.T) {
+	state := func(secret, encrypted string) *terraform.InstanceState {
+		return &terraform.InstanceState{
+			ID: "GK31c2f218a2e44f485b94239e",
+			Attributes: map[string]string{
+				"id":                  "GK31c2f218a2e44f485b94239e",
+				"name":                "app",
+				"access_key_id":       "GK31c2f218a2e44f485b94239e",
+				"secret_access_key":   secret,
+				"encrypted_secret":    encrypted,
+				"fetch_secret":        "true",
+				"allow_create_bucket": "false",
+				"buckets.#":           "0",
+			},
+		}
+	}
+	config := map[string]interface{}{"name": "app", "fetch_secret": true, "pgp_key": "armored key"}
+
+	tests := []struct {
+		name        string
+		state       *terraform.InstanceState
+		requiresNew bool
+	}{
+		{"imported without secret", state("", ""), false},
+		{"plaintext secret", state("b892c0665f0ada8a4755dae98baa3b133590e11dae3bcc1f9d769d67f16c3835", ""), true},
+		{"encrypted secret", state("", "d2NGTUE="), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := resourceGarageKey().Diff(context.Background(), tt.state, terraform.NewResourceConfigRaw(config), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff == nil || diff.Attributes["pgp_key"] == nil {
+				t.Fatalf("expected a pgp_key change, got %v", diff)
+			}
+			if diff.RequiresNew() != tt.requiresNew {
+				t.Errorf("RequiresNew() = %v, expected %v", diff.RequiresNew(), tt.requiresNew)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceGarageS3Object() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceGarageS3ObjectCreate,
+		ReadContext:   resourceGarageS3ObjectRead,
+		UpdateContext: resourceGarageS3ObjectUpdate,
+		DeleteContext: resourceGarageS3ObjectDelete,
+		CustomizeDiff: resourceGarageS3ObjectCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"bucket": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name (global alias) of the bucket",
+			},
+			"key": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The object key",
+			},
+			"content": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"content", "content_base64", "source"},
+				Description:  "Literal UTF-8 content of the object",
+			},
+			"content_base64": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"content", "content_base64", "source"},
+				Description:  "Base64-encoded binary content of the object",
+			},
+			"source": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"content", "content_base64", "source"},
+				Description:  "Path to a local file to upload",
+			},
+			"content_type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "MIME type of the object (guessed from the key extension if not set)",
+			},
+			"cache_control": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Cache-Control header of the object",
+			},
+			"metadata": {
+				Type:             schema.TypeMap,
+				Optional:         true,
+				Elem:             &schema.Schema{Type: schema.TypeString},
+				ValidateDiagFunc: validateMetadataKeys,
+				Description:      "User metadata of the object, sent as x-amz-meta-* headers. Keys must be lowercase",
+			},
+			"etag": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ETag of the object: the MD5 of its content, or of its part MD5s for multipart uploads",
+			},
+		},
+	}
+}
+
+func validateMetadataKeys(v interface{}, path cty.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for key := range v.(map[string]interface{}) {
+		if key != strings.ToLower(key) {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid metadata key",
+				Detail:        fmt.Sprintf("Metadata key %q must be lowercase, S3 lowercases metadata keys", key),
+				AttributePath: path,
+			})
+		}
+	}
+	return diags
+}
+
+// resourceGetter is implemented by both schema.ResourceData and schema.ResourceDiff.
+type resourceGetter interface {
+	Get(key string) interface{}
+}
+
+// s3ObjectContent opens the configured content of an object and returns its size.
+func s3ObjectContent(d resourceGetter) (io.ReadCloser, int64, error) {
+	if source := d.Get("source").(string); source != "" {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to open source: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, 0, fmt.Errorf("failed to stat source: %w", err)
+		}
+		return f, info.Size(), nil
+	}
+
+	content := []byte(d.Get("content").(string))
+	if v := d.Get("content_base64").(string); v != "" {
+		decoded, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode content_base64: %w", err)
+		}
+		content = decoded
+	}
+	return io.NopCloser(bytes.NewReader(content)), int64(len(content)), nil
+}
+
+// resourceGarageS3ObjectCustomizeDiff computes the ETag of the local content so that
+// a change of the content, or of the remote object, shows up as a diff.
+func resourceGarageS3ObjectCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	for _, k := range []string{"content", "content_base64", "source"} {
+		if !d.NewValueKnown(k) {
+			return d.SetNewComputed("etag")
+		}
+	}
+
+	content, size, err := s3ObjectContent(d)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = content.Close()
+	}()
+
+	etag, err := s3ETag(content, size)
+	if err != nil {
+		return fmt.Errorf("failed to compute ETag: %w", err)
+	}
+
+	if old, _ := d.GetChange("etag"); old.(string) != etag {
+		return d.SetNew("etag", etag)
+	}
+	return nil
+}
+
+func resourceGarageS3ObjectCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	bucket := d.Get("bucket").(string)
+	key := d.Get("key").(string)
+
+	if diags := resourceGarageS3ObjectUpload(ctx, d, m); diags.HasError() {
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", bucket, key))
+
+	return resourceGarageS3ObjectRead(ctx, d, m)
+}
+
+func resourceGarageS3ObjectRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	bucket := d.Get("bucket").(string)
+	key := d.Get("key").(string)
+
+	info, err := client.headObject(ctx, bucket, key)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to read object: %w", err))
+	}
+	if info == nil {
+		d.SetId("")
+		return nil
+	}
+
+	if err := d.Set("etag", info.ETag); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("content_type", info.ContentType); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("cache_control", info.CacheControl); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("metadata", info.Metadata); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceGarageS3ObjectUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Content and headers of an object cannot be changed in place, upload it again
+	if diags := resourceGarageS3ObjectUpload(ctx, d, m); diags.HasError() {
+		return diags
+	}
+
+	return resourceGarageS3ObjectRead(ctx, d, m)
+}
+
+func resourceGarageS3ObjectDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	bucket := d.Get("bucket").(string)
+	key := d.Get("key").(string)
+
+	if err := client.deleteObject(ctx, bucket, key); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to delete object: %w", err))
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceGarageS3ObjectUpload uploads the configured content and checks the resulting ETag.
+func resourceGarageS3ObjectUpload(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*GarageClient)
+	bucket := d.Get("bucket").(string)
+	key := d.Get("key").(string)
+
+	content, size, err := s3ObjectContent(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer func() {
+		_ = content.Close()
+	}()
+
+	contentType := d.Get("content_type").(string)
+	if contentType == "" {
+		contentType = guessContentType(key)
+	}
+	header := http.Header{"Content-Type": {contentType}}
+	if v := d.Get("cache_control").(string); v != "" {
+		header.Set("Cache-Control", v)
+	}
+	for k, v := range d.Get("metadata").(map[string]interface{}) {
+		header.Set("X-Amz-Meta-"+k, v.(string))
+	}
+
+	etag, err := client.uploadObject(ctx, bucket, key, content, size, header)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to upload object: %w", err))
+	}
+
+	if expected := d.Get("etag").(string); expected != "" && etag != "" && etag != expected {
+		return diag.FromErr(fmt.Errorf("uploaded object has ETag %s, expected %s: the content changed during upload", etag, expected))
+	}
+
+	return nil
+}
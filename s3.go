@@ -0,0 +1,625 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// s3PartSize is the part size of multipart uploads. Objects up to this size are
+	// uploaded with a single PutObject, larger objects with a multipart upload.
+	s3PartSize = 16 * 1024 * 1024
+
+	s3DefaultContentType = "application/octet-stream"
+)
+
+// sigV4Signer signs S3 requests with AWS Signature Version 4.
+type sigV4Signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+}
+
+// signRequest adds the X-Amz-Date, X-Amz-Content-Sha256 and Authorization headers to req.
+// The host and every header already set on req are signed.
+func (s sigV4Signer) signRequest(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.TrimSpace(v)
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+
+	canonicalRequest, signedHeaders := sigV4CanonicalRequest(req.Method, req.URL, headers, payloadHash)
+	scope := s.scope(amzDate)
+	signature := s.signature(amzDate, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature))
+}
+
+// presignURL adds the SigV4 query parameters to u so that it can be used without
+// credentials until expires after now. Only the host header is signed.
+func (s sigV4Signer) presignURL(method string, u *url.URL, expires time.Duration, now time.Time) *url.URL {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := s.scope(amzDate)
+
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	presigned := *u
+	presigned.RawQuery = s3CanonicalQuery(query)
+
+	canonicalRequest, _ := sigV4CanonicalRequest(method, &presigned, map[string]string{"host": u.Host}, "UNSIGNED-PAYLOAD")
+	presigned.RawQuery += "&X-Amz-Signature=" + s.signature(amzDate, scope, canonicalRequest)
+
+	return &presigned
+}
+
+func (s sigV4Signer) scope(amzDate string) string {
+	return fmt.Sprintf("%s/%s/s3/aws4_request", amzDate[:8], s.region)
+}
+
+func (s sigV4Signer) signature(amzDate, scope, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), amzDate[:8])
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// sigV4CanonicalRequest builds the canonical request of SigV4 and the list of signed headers.
+// headers must use lowercase names.
+func sigV4CanonicalRequest(method string, u *url.URL, headers map[string]string, payloadHash string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := u.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+
+	return strings.Join([]string{
+		method,
+		canonicalURI,
+		s3CanonicalQuery(u.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Escape encodes s as required by SigV4: every byte except unreserved characters
+// is percent-encoded, and slashes are kept when encoding a path.
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3CanonicalQuery encodes query parameters sorted by name, as required by SigV4.
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(query))
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(name, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// S3 addressing styles: the bucket goes either in the URL path or in the host name.
+const (
+	s3AddressingPath    = "path"
+	s3AddressingVirtual = "virtual"
+)
+
+// s3ObjectURL returns the URL of a bucket, or of an object when key is set. With the virtual
+// addressing style the bucket is prepended to the root domain when it is set, like in
+// buildBucketEndpoints, and to the endpoint host otherwise (bucket.s3.example.com).
+// The path and query are encoded as required by SigV4.
+func s3ObjectURL(endpoint, addressingStyle, rootDomain, bucket, key string, query url.Values) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: expected a URL such as https://s3.example.com", endpoint)
+	}
+
+	rawPath := strings.TrimSuffix(u.EscapedPath(), "/")
+	switch addressingStyle {
+	case s3AddressingPath, "":
+		rawPath += "/" + s3Escape(bucket, false)
+		if key != "" {
+			rawPath += "/" + s3Escape(key, true)
+		}
+	case s3AddressingVirtual:
+		if domain := strings.Trim(rootDomain, "."); domain != "" {
+			host := bucket + "." + domain
+			if port := u.Port(); port != "" {
+				host += ":" + port
+			}
+			u.Host = host
+			rawPath = ""
+		} else {
+			u.Host = bucket + "." + u.Host
+		}
+		rawPath += "/" + s3Escape(key, true)
+	default:
+		return nil, fmt.Errorf("invalid S3 addressing style %q: expected %q or %q", addressingStyle, s3AddressingPath, s3AddressingVirtual)
+	}
+
+	unescaped, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = unescaped
+	u.RawPath = rawPath
+	u.RawQuery = s3CanonicalQuery(query)
+
+	return u, nil
+}
+
+// s3URL returns the URL of a bucket, or of an object when key is set.
+func (c *GarageClient) s3URL(bucket, key string, query url.Values) (*url.URL, error) {
+	return s3ObjectURL(c.S3Endpoint, c.S3AddressingStyle, c.S3RootDomain, bucket, key, query)
+}
+
+// errMissingS3Credentials is returned for S3 requests when the provider has no S3 credentials.
+var errMissingS3Credentials = errors.New("the S3 API only accepts signed requests, set s3_access_key_id and s3_secret_access_key in the provider configuration")
+
+// hasS3Credentials reports whether S3 requests can be signed.
+func (c *GarageClient) hasS3Credentials() bool {
+	return c.S3AccessKeyID != "" && c.S3SecretAccessKey != ""
+}
+
+// s3Request sends a request to the S3 API, signed with SigV4 using the provider S3 credentials.
+func (c *GarageClient) s3Request(ctx context.Context, method, bucket, key string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	if !c.hasS3Credentials() {
+		return nil, errMissingS3Credentials
+	}
+
+	u, err := c.s3URL(bucket, key, query)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Keep the exact encoding used for signing
+	req.URL = u
+
+	for name, values := range header {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	if len(body) > 0 {
+		sum := md5.Sum(body)
+		req.Header.Set("Content-Md5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	payloadHash := sha256.Sum256(body)
+	signer := sigV4Signer{accessKeyID: c.S3AccessKeyID, secretAccessKey: c.S3SecretAccessKey, region: c.Region}
+	signer.signRequest(req, hex.EncodeToString(payloadHash[:]), time.Now())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	return resp, nil
+}
+
+// s3Error is an error response of the S3 API.
+type s3Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *s3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("%s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// checkS3Response returns an *s3Error when resp is not successful.
+func checkS3Response(resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	s3Err := &s3Error{StatusCode: resp.StatusCode}
+	if body, err := io.ReadAll(resp.Body); err == nil {
+		_ = xml.Unmarshal(body, s3Err)
+	}
+	return s3Err
+}
+
+// s3ObjectInfo holds the object headers returned by HeadObject.
+type s3ObjectInfo struct {
+	ETag          string
+	ContentType   string
+	CacheControl  string
+	ContentLength int64
+	Metadata      map[string]string
+}
+
+// headObject returns the headers of an object, or nil if the object does not exist.
+func (c *GarageClient) headObject(ctx context.Context, bucket, key string) (*s3ObjectInfo, error) {
+	resp, err := c.s3Request(ctx, http.MethodHead, bucket, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := checkS3Response(resp); err != nil {
+		return nil, err
+	}
+
+	info := &s3ObjectInfo{
+		ETag:          strings.Trim(resp.Header.Get("ETag"), `"`),
+		ContentType:   resp.Header.Get("Content-Type"),
+		CacheControl:  resp.Header.Get("Cache-Control"),
+		ContentLength: resp.ContentLength,
+		Metadata:      map[string]string{},
+	}
+	for name, values := range resp.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-meta-") && len(values) > 0 {
+			info.Metadata[strings.TrimPrefix(lower, "x-amz-meta-")] = values[0]
+		}
+	}
+
+	return info, nil
+}
+
+// deleteObject deletes an object. Deleting a missing object is not an error.
+func (c *GarageClient) deleteObject(ctx context.Context, bucket, key string) error {
+	resp, err := c.s3Request(ctx, http.MethodDelete, bucket, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkS3Response(resp)
+}
+
+// s3ListedObject is an object returned by ListObjectsV2.
+type s3ListedObject struct {
+	Key  string `xml:"Key"`
+	ETag string `xml:"ETag"`
+	Size int64  `xml:"Size"`
+}
+
+type listBucketResult struct {
+	Contents              []s3ListedObject `xml:"Contents"`
+	IsTruncated           bool             `xml:"IsTruncated"`
+	NextContinuationToken string           `xml:"NextContinuationToken"`
+}
+
+// listObjects returns every object of a bucket whose key starts with prefix.
+func (c *GarageClient) listObjects(ctx context.Context, bucket, prefix string) ([]s3ListedObject, error) {
+	var objects []s3ListedObject
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+
+	for {
+		resp, err := c.s3Request(ctx, http.MethodGet, bucket, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = checkS3Response(resp)
+		if err == nil {
+			err = xml.NewDecoder(resp.Body).Decode(&result)
+			if err != nil {
+				err = fmt.Errorf("failed to decode response: %w", err)
+			}
+		}
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range result.Contents {
+			object.ETag = strings.Trim(object.ETag, `"`)
+			objects = append(objects, object)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// uploadObject uploads size bytes read from r, with a multipart upload when the object
+// is larger than s3PartSize, and returns the ETag reported by Garage.
+func (c *GarageClient) uploadObject(ctx context.Context, bucket, key string, r io.Reader, size int64, header http.Header) (string, error) {
+	if size <= s3PartSize {
+		body := make([]byte, size)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return "", fmt.Errorf("failed to read object content: %w", err)
+		}
+		return c.putObject(ctx, bucket, key, body, header)
+	}
+
+	uploadID, err := c.createMultipartUpload(ctx, bucket, key, header)
+	if err != nil {
+		return "", err
+	}
+
+	etag, err := c.uploadParts(ctx, bucket, key, uploadID, r, size)
+	if err != nil {
+		if abortErr := c.abortMultipartUpload(ctx, bucket, key, uploadID); abortErr != nil {
+			return "", fmt.Errorf("%w (aborting the upload also failed: %v)", err, abortErr)
+		}
+		return "", err
+	}
+
+	return etag, nil
+}
+
+func (c *GarageClient) putObject(ctx context.Context, bucket, key string, body []byte, header http.Header) (string, error) {
+	resp, err := c.s3Request(ctx, http.MethodPut, bucket, key, nil, body, header)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	if err := checkS3Response(resp); err != nil {
+		return "", fmt.Errorf("failed to upload object: %w", err)
+	}
+	return strings.Trim(resp.Header.Get("ETag"), `"`), nil
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName xml.Name
+	ETag    string `xml:"ETag"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (c *GarageClient) createMultipartUpload(ctx context.Context, bucket, key string, header http.Header) (string, error) {
+	resp, err := c.s3Request(ctx, http.MethodPost, bucket, key, url.Values{"uploads": {""}}, nil, header)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	if err := checkS3Response(resp); err != nil {
+		return "", fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+
+	var result initiateMultipartUploadResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result.UploadID, nil
+}
+
+func (c *GarageClient) uploadParts(ctx context.Context, bucket, key, uploadID string, r io.Reader, size int64) (string, error) {
+	var complete completeMultipartUpload
+	buf := make([]byte, s3PartSize)
+
+	for partNumber, remaining := 1, size; remaining > 0; partNumber++ {
+		n := int64(s3PartSize)
+		if remaining < n {
+			n = remaining
+		}
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return "", fmt.Errorf("failed to read object content: %w", err)
+		}
+		remaining -= n
+
+		query := url.Values{
+			"partNumber": {strconv.Itoa(partNumber)},
+			"uploadId":   {uploadID},
+		}
+		resp, err := c.s3Request(ctx, http.MethodPut, bucket, key, query, buf[:n], nil)
+		if err != nil {
+			return "", err
+		}
+		err = checkS3Response(resp)
+		etag := resp.Header.Get("ETag")
+		_ = resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+		}
+
+		complete.Parts = append(complete.Parts, completedPart{PartNumber: partNumber, ETag: etag})
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal parts: %w", err)
+	}
+
+	resp, err := c.s3Request(ctx, http.MethodPost, bucket, key, url.Values{"uploadId": {uploadID}}, body,
+		http.Header{"Content-Type": {"application/xml"}})
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	if err := checkS3Response(resp); err != nil {
+		return "", fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	// CompleteMultipartUpload can report an error with a 200 status code
+	var result completeMultipartUploadResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.XMLName.Local == "Error" {
+		return "", fmt.Errorf("failed to complete multipart upload: %s: %s", result.Code, result.Message)
+	}
+
+	return strings.Trim(result.ETag, `"`), nil
+}
+
+func (c *GarageClient) abortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	resp, err := c.s3Request(ctx, http.MethodDelete, bucket, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	return checkS3Response(resp)
+}
+
+// s3ETag computes the ETag Garage reports for content uploaded with uploadObject:
+// the MD5 of the content, or for multipart uploads the MD5 of the part MD5s
+// followed by the number of parts.
+func s3ETag(r io.Reader, size int64) (string, error) {
+	if size <= s3PartSize {
+		h := md5.New()
+		if _, err := io.Copy(h, r); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	var partSums []byte
+	parts := 0
+	for remaining := size; remaining > 0; parts++ {
+		n := int64(s3PartSize)
+		if remaining < n {
+			n = remaining
+		}
+		h := md5.New()
+		if _, err := io.CopyN(h, r, n); err != nil {
+			return "", err
+		}
+		partSums = h.Sum(partSums)
+		remaining -= n
+	}
+
+	sum := md5.Sum(partSums)
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), parts), nil
+}
+
+// guessContentType returns the content type of a file name based on its extension.
+func guessContentType(name string) string {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return s3DefaultContentType
+}
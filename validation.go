@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// bucketNameError returns why name is not a valid bucket name, following the rules Garage
+// enforces for global and local aliases, or nil if the name is valid.
+func bucketNameError(name string) error {
+	if len(name) < 3 || len(name) > 63 {
+		return fmt.Errorf("must be between 3 and 63 characters long, got %d", len(name))
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '.' {
+			return fmt.Errorf("must only contain lowercase letters, numbers, dashes and dots, got %q", c)
+		}
+	}
+	if strings.HasPrefix(name, "-") || strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, "-") || strings.HasSuffix(name, ".") {
+		return fmt.Errorf("must start and end with a letter or a number")
+	}
+	if net.ParseIP(name) != nil {
+		return fmt.Errorf("must not be formatted as an IP address")
+	}
+	if strings.HasPrefix(name, "xn--") {
+		return fmt.Errorf("must not start with \"xn--\"")
+	}
+	if strings.HasSuffix(name, "-s3alias") {
+		return fmt.Errorf("must not end with \"-s3alias\"")
+	}
+	return nil
+}
+
+func validateBucketName(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value == "" {
+		return
+	}
+	if err := bucketNameError(value); err != nil {
+		errors = append(errors, fmt.Errorf("%q is not a valid bucket name: %w", k, err))
+	}
+	return
+}
+
+// accessKeyIDError returns why id is not a valid Garage access key ID ("GK" followed by
+// 12 hex-encoded bytes), or nil if the ID is valid. Garage rejects other formats on import.
+func accessKeyIDError(id string) error {
+	if !strings.HasPrefix(id, "GK") {
+		return fmt.Errorf("must start with \"GK\"")
+	}
+	if len(id) != 26 {
+		return fmt.Errorf("must be 26 characters long (\"GK\" followed by 24 hex digits), got %d", len(id))
+	}
+	if _, err := hex.DecodeString(id[2:]); err != nil {
+		return fmt.Errorf("must be \"GK\" followed by 24 hex digits")
+	}
+	return nil
+}
+
+// secretAccessKeyError returns why secret is not a valid Garage secret access key
+// (32 hex-encoded bytes), or nil if the secret is valid.
+func secretAccessKeyError(secret string) error {
+	if len(secret) != 64 {
+		return fmt.Errorf("must be 64 hex digits long, got %d characters", len(secret))
+	}
+	if _, err := hex.DecodeString(secret); err != nil {
+		return fmt.Errorf("must only contain hex digits")
+	}
+	return nil
+}
+
+func validateAccessKeyID(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value == "" {
+		return
+	}
+	if err := accessKeyIDError(value); err != nil {
+		errors = append(errors, fmt.Errorf("%q is not a valid Garage access key ID: %w", k, err))
+	}
+	return
+}
+
+// validateSecretAccessKey does not include the value in errors, as it is a secret.
+func validateSecretAccessKey(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value == "" {
+		return
+	}
+	if err := secretAccessKeyError(value); err != nil {
+		errors = append(errors, fmt.Errorf("%q is not a valid Garage secret access key: %w", k, err))
+	}
+	return
+}